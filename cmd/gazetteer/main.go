@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"weather-reporter/internal/gazetteer"
+)
+
+// main converts a GeoNames cities dump read from stdin, e.g. cities15000.txt, to the gazetteer CSV on stdout.
+func main() {
+	if err := gazetteer.ConvertGeoNames(os.Stdin, os.Stdout); err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Fatal("failed to convert geonames dump")
+	}
+}
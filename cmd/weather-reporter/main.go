@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	h "net/http"
@@ -8,6 +9,7 @@ import (
 	"os/signal"
 	"syscall"
 	"weather-reporter/internal"
+	"weather-reporter/internal/gazetteer"
 	"weather-reporter/internal/http"
 	"weather-reporter/internal/weather"
 	"weather-reporter/internal/weather/providers"
@@ -29,14 +31,158 @@ func init() {
 		Transport: http.InstrumentHttpTransport("openWeatherMap", h.DefaultTransport),
 	}, config.OpenWeatherMapAppID)
 
-	cache := weather.NewWeatherCache(config.CacheExpiration)
+	bomStations, err := providers.ParseBomStations(config.BomStations)
+	if err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Fatal("failed to parse bom stations")
+	}
+
+	openMeteoWeatherProvider := providers.NewOpenMeteoWeatherProvider(h.Client{
+		Timeout:   config.HttpClientTimeout,
+		Transport: http.InstrumentHttpTransport("openMeteo", h.DefaultTransport),
+	}, config.OpenMeteoUrl)
+
+	metarWeatherProvider := providers.NewMetarWeatherProvider(h.Client{
+		Timeout:   config.HttpClientTimeout,
+		Transport: http.InstrumentHttpTransport("metar", h.DefaultTransport),
+	}, config.AviationWeatherUrl)
+
+	breakerConfig := weather.BreakerConfig{
+		FailureRatio: config.BreakerFailureRatio,
+		MinRequests:  config.BreakerMinRequests,
+		Window:       config.BreakerWindow,
+		CoolDown:     config.BreakerCoolDown,
+	}
+	breakers := []weather.CircuitBreaker{
+		weather.NewCircuitBreakerProvider("yahoo", yahooWeatherProvider, breakerConfig),
+		weather.NewCircuitBreakerProvider("openWeatherMap", openWeatherMapWeatherProvider, breakerConfig),
+	}
+	if err := providers.CheckUserAgent(config.BomUserAgent); err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Warn("invalid bom user agent; skipping the provider")
+	} else {
+		bomWeatherProvider := providers.NewBomWeatherProvider(h.Client{
+			Timeout:   config.HttpClientTimeout,
+			Transport: http.InstrumentHttpTransport("bom", h.DefaultTransport),
+		}, config.BomUrl, config.BomUserAgent, bomStations)
+		breakers = append(breakers, weather.NewCircuitBreakerProvider("bom", bomWeatherProvider, breakerConfig))
+	}
+	breakers = append(breakers, weather.NewCircuitBreakerProvider("openMeteo", openMeteoWeatherProvider, breakerConfig))
+	if err := providers.CheckUserAgent(config.MetNorwayUserAgent); err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Warn("invalid met norway user agent; skipping the provider")
+	} else {
+		metNorwayWeatherProvider := providers.NewMetNorwayWeatherProvider(h.Client{
+			Timeout:   config.HttpClientTimeout,
+			Transport: http.InstrumentHttpTransport("metNorway", h.DefaultTransport),
+		}, config.MetNorwayUrl, config.MetNorwayUserAgent)
+		breakers = append(breakers,
+			weather.NewCircuitBreakerProvider("metNorway", metNorwayWeatherProvider, breakerConfig))
+	}
+	if err := providers.CheckUserAgent(config.NwsUserAgent); err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Warn("invalid nws user agent; skipping the provider")
+	} else {
+		nwsWeatherProvider := providers.NewNwsWeatherProvider(h.Client{
+			Timeout:   config.HttpClientTimeout,
+			Transport: http.InstrumentHttpTransport("nws", h.DefaultTransport),
+		}, config.NwsUrl, config.NwsUserAgent)
+		breakers = append(breakers, weather.NewCircuitBreakerProvider("nws", nwsWeatherProvider, breakerConfig))
+	}
+	breakers = append(breakers, weather.NewCircuitBreakerProvider("metar", metarWeatherProvider, breakerConfig))
+	weatherProviders := make([]weather.Provider, len(breakers))
+	for i, breaker := range breakers {
+		weatherProviders[i] = breaker
+	}
+
+	places, err := gazetteer.LoadGazetteer(config.GazetteerFile)
+	if err != nil {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Fatal("failed to load gazetteer")
+	}
+
+	cache := weather.NewWeatherCache(config.CacheFreshTTL, config.CacheRevalidateTTL, config.CacheStaleTTL)
+
+	serviceConfig := weather.ServiceConfig{
+		RefreshConcurrency: config.CacheRefreshConcurrency,
+		RefreshMaxAttempts: config.CacheRefreshMaxAttempts,
+		HedgeDelay:         config.ProviderHedgeDelay,
+		RequestBudget:      config.RequestBudget,
+		Resolver:           places,
+	}
+	weatherProcessor := weather.NewWeatherService(cache, serviceConfig, weatherProviders...)
+	handler := func(ctx context.Context, request http.WeatherRequest) (interface{}, weather.Metadata, error) {
+		w, metadata, err := weatherProcessor.GetCurrentWeather(ctx, request.Location)
+		if err != nil {
+			return nil, weather.Metadata{}, err
+		}
+		return w.Convert(request.Units).Round(request.Precision), metadata, nil
+	}
+
+	batchService := weather.NewBatchService(weatherProcessor, config.BatchConcurrency)
+	batchHandler := func(ctx context.Context, request http.BatchWeatherRequest) (interface{}, error) {
+		results := batchService.GetCurrentWeatherBatch(ctx, request.Locations)
+		for city, result := range results {
+			if result.Weather != nil {
+				w := result.Weather.Convert(request.Units).Round(request.Precision)
+				results[city] = weather.BatchResult{Weather: &w}
+			}
+		}
+		for city, detail := range request.Invalid {
+			results[city] = weather.BatchResult{Error: detail}
+		}
+		return results, nil
+	}
+
+	forecastCache := weather.NewForecastCache(
+		config.ForecastCacheFreshTTL, config.ForecastCacheRevalidateTTL, config.ForecastCacheStaleTTL)
+	forecastService := weather.NewForecastService(forecastCache, serviceConfig, weatherProviders...)
+	forecastHandler := func(ctx context.Context, request http.ForecastRequest) (interface{}, weather.Metadata, error) {
+		f, metadata, err := forecastService.GetForecast(ctx, request.Location, request.Days)
+		if err != nil {
+			return nil, weather.Metadata{}, err
+		}
+		return f.Convert(request.Units).Round(request.Precision), metadata, nil
+	}
+
+	hourlyCache := weather.NewHourlyForecastCache(
+		config.HourlyCacheFreshTTL, config.HourlyCacheRevalidateTTL, config.HourlyCacheStaleTTL)
+	var hourlyProviders []weather.Provider
+	for _, breaker := range breakers {
+		// the hourly forecast of openWeatherMap is served to paid plans only
+		if breaker.Name() == "openWeatherMap" && !config.OpenWeatherMapPro {
+			continue
+		}
+		hourlyProviders = append(hourlyProviders, breaker)
+	}
+	hourlyService := weather.NewHourlyForecastService(hourlyCache, serviceConfig, hourlyProviders...)
+	hourlyHandler := func(ctx context.Context, request http.HourlyForecastRequest) (interface{}, weather.Metadata, error) {
+		f, metadata, err := hourlyService.GetHourlyForecast(ctx, request.Location, request.Hours)
+		if err != nil {
+			return nil, weather.Metadata{}, err
+		}
+		return f.Convert(request.Units).Round(request.Precision), metadata, nil
+	}
 
-	weatherProcessor := weather.NewWeatherService(cache, yahooWeatherProvider, openWeatherMapWeatherProvider)
-	handler := func(weather string) (interface{}, error) {
-		return weatherProcessor.GetCurrentWeather(weather)
+	locationsHandler := func(ctx context.Context, request http.LocationsRequest) (interface{}, error) {
+		return places.Search(request.Query, request.Limit), nil
+	}
+
+	validation := http.NoValidation
+	if config.ValidateRequests {
+		validation = http.ValidateRequests
+	}
+
+	healthReporter := func() map[string]interface{} {
+		providerStates := make(map[string]string, len(breakers))
+		for _, breaker := range breakers {
+			providerStates[breaker.Name()] = breaker.State().String()
+		}
+		return map[string]interface{}{"providers": providerStates}
 	}
 
-	httpServer = http.NewHttpServer(config.HttpPort, http.CreateWeatherHttpRouter(handler))
+	httpServer = http.NewHttpServer(config.HttpPort, validation, healthReporter,
+		http.CreateWeatherHttpRouter(handler),
+		http.CreateBatchWeatherHttpRouter(batchHandler, config.BatchMaxCities),
+		http.CreateForecastHttpRouter(forecastHandler),
+		http.CreateHourlyForecastHttpRouter(hourlyHandler),
+		http.CreateLocationsHttpRouter(locationsHandler),
+	)
 }
 
 func main() {
@@ -56,7 +202,7 @@ func main() {
 }
 
 func waitForShutdown(shutdownHook func()) {
-	var gracefulStop = make(chan os.Signal)
+	var gracefulStop = make(chan os.Signal, 1)
 	signal.Notify(gracefulStop, syscall.SIGTERM)
 	signal.Notify(gracefulStop, syscall.SIGINT)
 	sig := <-gracefulStop
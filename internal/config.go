@@ -5,13 +5,44 @@ import (
 	log "github.com/sirupsen/logrus"
 	"strings"
 	"time"
+	"weather-reporter/internal/weather/providers"
 )
 
 type Config struct {
-	HttpPort            int
-	HttpClientTimeout   time.Duration
-	OpenWeatherMapAppID string
-	CacheExpiration     time.Duration
+	HttpPort                   int
+	HttpClientTimeout          time.Duration
+	OpenWeatherMapAppID        string
+	OpenWeatherMapPro          bool
+	OpenMeteoUrl               string
+	MetNorwayUrl               string
+	MetNorwayUserAgent         string
+	NwsUrl                     string
+	NwsUserAgent               string
+	BomUrl                     string
+	BomUserAgent               string
+	BomStations                string
+	AviationWeatherUrl         string
+	CacheFreshTTL              time.Duration
+	CacheRevalidateTTL         time.Duration
+	CacheStaleTTL              time.Duration
+	CacheRefreshConcurrency    int
+	CacheRefreshMaxAttempts    int
+	BreakerFailureRatio        float64
+	BreakerMinRequests         int
+	BreakerWindow              time.Duration
+	BreakerCoolDown            time.Duration
+	ProviderHedgeDelay         time.Duration
+	RequestBudget              time.Duration
+	ForecastCacheFreshTTL      time.Duration
+	ForecastCacheRevalidateTTL time.Duration
+	ForecastCacheStaleTTL      time.Duration
+	HourlyCacheFreshTTL        time.Duration
+	HourlyCacheRevalidateTTL   time.Duration
+	HourlyCacheStaleTTL        time.Duration
+	BatchConcurrency           int
+	BatchMaxCities             int
+	GazetteerFile              string
+	ValidateRequests           bool
 }
 
 func NewConfig() Config {
@@ -24,7 +55,104 @@ func NewConfig() Config {
 	flag.StringVar(&config.OpenWeatherMapAppID, "open_weather_map_app_id", "_REPLACE_",
 		"The App ID for the OpenWeatherMap provider")
 
-	flag.DurationVar(&config.CacheExpiration, "cache_expiration", time.Second*60, "The weather cache expiration time")
+	flag.BoolVar(&config.OpenWeatherMapPro, "open_weather_map_pro", false,
+		"Whether the OpenWeatherMap App ID is of a paid plan, which is required for hourly forecasts")
+
+	flag.StringVar(&config.OpenMeteoUrl, "open_meteo_url", providers.OpenMeteoUrl,
+		"The base url of the Open-Meteo provider")
+
+	flag.StringVar(&config.MetNorwayUrl, "met_norway_url", providers.MetNorwayUrl,
+		"The base url of the MET Norway provider")
+
+	flag.StringVar(&config.MetNorwayUserAgent, "met_norway_user_agent", "",
+		"The User-Agent identifying the service to the MET Norway provider, which must include a contact, "+
+			"e.g. \"weather-reporter/1.0 you@example.com\"")
+
+	flag.StringVar(&config.NwsUrl, "nws_url", providers.NwsUrl,
+		"The base url of the US National Weather Service provider")
+
+	flag.StringVar(&config.NwsUserAgent, "nws_user_agent", "",
+		"The User-Agent identifying the service to the US National Weather Service provider, which must include a contact, "+
+			"e.g. \"weather-reporter/1.0 you@example.com\"")
+
+	flag.StringVar(&config.BomUrl, "bom_url", providers.BomUrl,
+		"The base url of the Bureau of Meteorology provider")
+
+	flag.StringVar(&config.BomUserAgent, "bom_user_agent", "",
+		"The User-Agent identifying the service to the Bureau of Meteorology provider, which must include a contact, "+
+			"e.g. \"weather-reporter/1.0 you@example.com\"")
+
+	flag.StringVar(&config.BomStations, "bom_stations", providers.BomStations,
+		"The comma separated Bureau of Meteorology station feeds of cities, e.g. sydney=IDN60901.94768")
+
+	flag.StringVar(&config.AviationWeatherUrl, "aviation_weather_url", providers.AviationWeatherUrl,
+		"The base url of the Aviation Weather Center serving METAR reports")
+
+	flag.DurationVar(&config.CacheFreshTTL, "cache_fresh_ttl", time.Second*3,
+		"The time a cached weather is served without calling providers")
+
+	var cacheExpiration time.Duration
+	flag.DurationVar(&cacheExpiration, "cache_expiration", 0,
+		"Deprecated: use cache_fresh_ttl, which it sets when given")
+
+	flag.DurationVar(&config.CacheRevalidateTTL, "cache_revalidate_ttl", time.Second*10,
+		"The time a cached weather is served while being refreshed in background")
+
+	flag.DurationVar(&config.CacheStaleTTL, "cache_stale_ttl", time.Second*60,
+		"The time a cached weather is kept to be served if all providers are down")
+
+	flag.IntVar(&config.CacheRefreshConcurrency, "cache_refresh_concurrency", 10,
+		"The maximum number of background cache refreshes running at once. Zero disables background refresh")
+
+	flag.IntVar(&config.CacheRefreshMaxAttempts, "cache_refresh_max_attempts", 3,
+		"The maximum number of consecutive failed background refreshes of a city")
+
+	flag.Float64Var(&config.BreakerFailureRatio, "breaker_failure_ratio", 0.5,
+		"The ratio of failed provider requests within a window that opens the provider circuit breaker")
+
+	flag.IntVar(&config.BreakerMinRequests, "breaker_min_requests", 5,
+		"The minimum number of provider requests within a window before the circuit breaker may open")
+
+	flag.DurationVar(&config.BreakerWindow, "breaker_window", time.Second*30,
+		"The interval provider failures are counted in by the circuit breaker")
+
+	flag.DurationVar(&config.BreakerCoolDown, "breaker_cool_down", time.Second*15,
+		"The time the provider circuit breaker stays open before letting a trial request through")
+
+	flag.DurationVar(&config.ProviderHedgeDelay, "provider_hedge_delay", time.Millisecond*500,
+		"The time to wait for a weather provider before calling the next one in parallel. Zero disables hedging")
+
+	flag.DurationVar(&config.RequestBudget, "request_budget", time.Second*5,
+		"The overall deadline of a weather lookup, split across weather providers")
+
+	flag.DurationVar(&config.ForecastCacheFreshTTL, "forecast_cache_fresh_ttl", time.Minute*10,
+		"The time a cached forecast is served without calling providers")
+
+	flag.DurationVar(&config.ForecastCacheRevalidateTTL, "forecast_cache_revalidate_ttl", time.Minute*30,
+		"The time a cached forecast is served while being refreshed in background")
+
+	flag.DurationVar(&config.ForecastCacheStaleTTL, "forecast_cache_stale_ttl", time.Hour*6,
+		"The time a cached forecast is kept to be served if all providers are down")
+
+	flag.DurationVar(&config.HourlyCacheFreshTTL, "hourly_cache_fresh_ttl", time.Minute*10,
+		"The time a cached hourly forecast is served without calling providers")
+
+	flag.DurationVar(&config.HourlyCacheRevalidateTTL, "hourly_cache_revalidate_ttl", time.Minute*30,
+		"The time a cached hourly forecast is served while being refreshed in background")
+
+	flag.DurationVar(&config.HourlyCacheStaleTTL, "hourly_cache_stale_ttl", time.Hour*3,
+		"The time a cached hourly forecast is kept to be served if all providers are down")
+
+	flag.IntVar(&config.BatchConcurrency, "batch_concurrency", 8,
+		"The maximum number of cities of a batch request looked up at once")
+
+	flag.IntVar(&config.BatchMaxCities, "batch_max_cities", 100, "The maximum number of cities in a batch request")
+
+	flag.StringVar(&config.GazetteerFile, "gazetteer_file", "",
+		"The path to a csv file of places replacing the embedded gazetteer")
+
+	flag.BoolVar(&config.ValidateRequests, "validate_requests", false,
+		"Enable validation of requests against the OpenAPI specification")
 
 	var logFormat string
 	flag.StringVar(&logFormat, "log_format", "text", "The format of the logs. Either text, or json")
@@ -44,5 +172,11 @@ func NewConfig() Config {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if cacheExpiration > 0 {
+		log.WithField("cache_expiration", cacheExpiration).
+			Warn("cache_expiration is deprecated, use cache_fresh_ttl instead")
+		config.CacheFreshTTL = cacheExpiration
+	}
+
 	return config
 }
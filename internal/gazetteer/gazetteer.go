@@ -0,0 +1,235 @@
+package gazetteer
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"weather-reporter/internal/weather"
+)
+
+// citiesCSV is a subset of the GeoNames cities dataset with the columns
+// geonameid, name, country_code, latitude, longitude, population and alternate_names separated by "|".
+//
+//go:embed cities.csv
+var citiesCSV []byte
+
+// Gazetteer resolves free-text city names to canonical places.
+type Gazetteer interface {
+	weather.Resolver
+	// Search returns up to limit places whose name or alias starts with the query, the most populated first.
+	Search(query string, limit int) []Place
+}
+
+// Place is a city of the gazetteer.
+type Place struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Country    string  `json:"country"`
+	Latitude   float64 `json:"lat"`
+	Longitude  float64 `json:"lon"`
+	Population int     `json:"population"`
+}
+
+// Location returns the location of the place, which is looked up by its coordinates and cached by its ID.
+func (p Place) Location() weather.Location {
+	return weather.Location{
+		ID:          p.ID,
+		City:        p.Name,
+		Country:     p.Country,
+		Coordinates: &weather.Coordinates{Latitude: p.Latitude, Longitude: p.Longitude},
+	}
+}
+
+// LoadGazetteer loads the gazetteer from the CSV file, or from the embedded dataset when the path is empty.
+func LoadGazetteer(path string) (Gazetteer, error) {
+	if path == "" {
+		return NewGazetteer(bytes.NewReader(citiesCSV))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open gazetteer file")
+	}
+	defer file.Close()
+	return NewGazetteer(file)
+}
+
+// NewGazetteer reads places from CSV data with a header and the columns of the embedded dataset.
+func NewGazetteer(data io.Reader) (Gazetteer, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = 7
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read gazetteer csv")
+	}
+	if len(records) < 2 {
+		return nil, errors.New("gazetteer has no places")
+	}
+	g := &gazetteer{names: make(map[string][]*Place)}
+	for i, record := range records[1:] {
+		place, err := parsePlace(record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse gazetteer line %v", i+2)
+		}
+		g.add(place, record[1], strings.Split(record[6], "|"))
+	}
+	for name := range g.names {
+		g.sortedNames = append(g.sortedNames, name)
+	}
+	sort.Strings(g.sortedNames)
+	return g, nil
+}
+
+func parsePlace(record []string) (*Place, error) {
+	latitude, err := strconv.ParseFloat(record[3], 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert latitude %v to number", record[3])
+	}
+	longitude, err := strconv.ParseFloat(record[4], 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert longitude %v to number", record[4])
+	}
+	population, err := strconv.Atoi(record[5])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert population %v to number", record[5])
+	}
+	return &Place{
+		ID:         "geonames:" + record[0],
+		Name:       record[1],
+		Country:    strings.ToUpper(record[2]),
+		Latitude:   latitude,
+		Longitude:  longitude,
+		Population: population,
+	}, nil
+}
+
+type gazetteer struct {
+	// names maps normalized names and aliases to their places
+	names map[string][]*Place
+	// sortedNames keeps the names in order for prefix searches
+	sortedNames []string
+}
+
+func (g *gazetteer) add(place *Place, name string, aliases []string) {
+	for _, n := range append([]string{name}, aliases...) {
+		key := normalize(n)
+		if key == "" || containsPlace(g.names[key], place) {
+			continue
+		}
+		g.names[key] = append(g.names[key], place)
+	}
+}
+
+// Resolve resolves a city to the most populated place of its name or alias, allowing misspellings
+// when there is no exact match. Locations with coordinates or an ID are returned as given.
+func (g *gazetteer) Resolve(location weather.Location) (weather.Location, error) {
+	if location.Coordinates != nil || location.ID != "" {
+		return location, nil
+	}
+	name := normalize(location.City)
+	if place := mostPopulated(g.names[name], location.Country); place != nil {
+		resolutionMetric.WithLabelValues("exact").Inc()
+		return place.Location(), nil
+	}
+	if place := g.resolveMisspelling(name, location.Country); place != nil {
+		resolutionMetric.WithLabelValues("fuzzy").Inc()
+		return place.Location(), nil
+	}
+	resolutionMetric.WithLabelValues("unknown").Inc()
+	return weather.Location{}, errors.Wrapf(weather.ErrUnknownLocation, "%v", location)
+}
+
+// resolveMisspelling finds places of the names closest to the name within the allowed edit distance.
+func (g *gazetteer) resolveMisspelling(name string, country string) *Place {
+	maxDistance := allowedDistance(name)
+	if maxDistance == 0 {
+		return nil
+	}
+	var closest []*Place
+	closestDistance := maxDistance + 1
+	for _, candidate := range g.sortedNames {
+		distance := editDistance(name, candidate, closestDistance+1)
+		if distance > maxDistance {
+			continue
+		}
+		if distance < closestDistance {
+			closest = nil
+			closestDistance = distance
+		}
+		if distance == closestDistance {
+			closest = append(closest, g.names[candidate]...)
+		}
+	}
+	return mostPopulated(closest, country)
+}
+
+func (g *gazetteer) Search(query string, limit int) []Place {
+	prefix := normalize(query)
+	if prefix == "" || limit < 1 {
+		return []Place{}
+	}
+	var found []*Place
+	start := sort.SearchStrings(g.sortedNames, prefix)
+	for _, name := range g.sortedNames[start:] {
+		if !strings.HasPrefix(name, prefix) {
+			break
+		}
+		for _, place := range g.names[name] {
+			if !containsPlace(found, place) {
+				found = append(found, place)
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Population > found[j].Population
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	places := make([]Place, len(found))
+	for i, place := range found {
+		places[i] = *place
+	}
+	return places
+}
+
+// mostPopulated returns the most populated place of the country, or of any country when it is empty.
+func mostPopulated(places []*Place, country string) *Place {
+	var best *Place
+	for _, place := range places {
+		if country != "" && !strings.EqualFold(place.Country, country) {
+			continue
+		}
+		if best == nil || place.Population > best.Population {
+			best = place
+		}
+	}
+	return best
+}
+
+func containsPlace(places []*Place, place *Place) bool {
+	for _, p := range places {
+		if p == place {
+			return true
+		}
+	}
+	return false
+}
+
+var resolutionMetric = registerResolutionMetric()
+
+func registerResolutionMetric() *prometheus.CounterVec {
+	metric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weather_reporter",
+		Name:      "gazetteer_resolutions_total",
+		Help:      "Counter of city resolutions by result: exact, fuzzy or unknown.",
+	}, []string{"result"})
+	prometheus.MustRegister(metric)
+	return metric
+}
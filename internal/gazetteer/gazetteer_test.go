@@ -0,0 +1,129 @@
+package gazetteer
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"weather-reporter/internal/weather"
+)
+
+func loadEmbedded(t *testing.T) Gazetteer {
+	g, err := LoadGazetteer("")
+	assert.NoError(t, err)
+	return g
+}
+
+func Test_Should_Load_Embedded_Gazetteer(t *testing.T) {
+	g := loadEmbedded(t)
+	assert.NotEmpty(t, g.Search("s", 100))
+}
+
+func Test_Should_Resolve_City_To_Canonical_Place(t *testing.T) {
+	g := loadEmbedded(t)
+	location, err := g.Resolve(weather.Location{City: "sydney"})
+	assert.NoError(t, err)
+	assert.Equal(t, "geonames:2147714", location.ID)
+	assert.Equal(t, "Sydney", location.City)
+	assert.Equal(t, "AU", location.Country)
+	assert.InDelta(t, -33.8679, location.Coordinates.Latitude, 0.0001)
+}
+
+func Test_Should_Resolve_City_Of_Country(t *testing.T) {
+	g := loadEmbedded(t)
+	location, err := g.Resolve(weather.Location{City: "Sydney", Country: "CA"})
+	assert.NoError(t, err)
+	assert.Equal(t, "geonames:6354908", location.ID)
+}
+
+func Test_Should_Resolve_Aliases_And_Diacritics(t *testing.T) {
+	g := loadEmbedded(t)
+	for query, id := range map[string]string{
+		"NYC":         "geonames:5128581",
+		"Bombay":      "geonames:1275339",
+		"sao paulo":   "geonames:3448439",
+		"São Paulo":   "geonames:3448439",
+		"Saint Johns": "geonames:6324733",
+		"st. john's":  "geonames:6324733",
+	} {
+		location, err := g.Resolve(weather.Location{City: query})
+		assert.NoError(t, err, query)
+		assert.Equal(t, id, location.ID, query)
+	}
+}
+
+func Test_Should_Resolve_Misspelled_City(t *testing.T) {
+	g := loadEmbedded(t)
+	for _, query := range []string{"Sydeny", "Melborne", "Brisbaen", "Amsterdm"} {
+		location, err := g.Resolve(weather.Location{City: query})
+		assert.NoError(t, err, query)
+		assert.NotEmpty(t, location.ID, query)
+	}
+}
+
+func Test_Should_Return_Unknown_Location(t *testing.T) {
+	g := loadEmbedded(t)
+	for _, location := range []weather.Location{{City: "Gotham"}, {City: "Rio", Country: "AU"}, {City: "Rom"}} {
+		_, err := g.Resolve(location)
+		assert.Equal(t, weather.ErrUnknownLocation, errors.Cause(err), location.String())
+	}
+}
+
+func Test_Should_Not_Resolve_Coordinates(t *testing.T) {
+	g := loadEmbedded(t)
+	coordinates := weather.Location{Coordinates: &weather.Coordinates{Latitude: 1, Longitude: 2}}
+	location, err := g.Resolve(coordinates)
+	assert.NoError(t, err)
+	assert.Equal(t, coordinates, location)
+}
+
+func Test_Should_Search_Places_By_Prefix_Most_Populated_First(t *testing.T) {
+	g := loadEmbedded(t)
+	places := g.Search("syd", 10)
+	assert.Len(t, places, 2)
+	assert.Equal(t, "AU", places[0].Country)
+	assert.Equal(t, "CA", places[1].Country)
+	assert.Len(t, g.Search("s", 3), 3)
+	assert.Empty(t, g.Search("", 10))
+}
+
+func Test_Should_Return_Error_For_Invalid_Gazetteer(t *testing.T) {
+	_, err := NewGazetteer(strings.NewReader("geonameid,name,country_code,latitude,longitude,population,alternate_names\n1,X,AU,north,0,0,\n"))
+	assert.Contains(t, err.Error(), "failed to parse gazetteer line 2")
+	_, err = NewGazetteer(strings.NewReader("geonameid,name,country_code,latitude,longitude,population,alternate_names\n"))
+	assert.Contains(t, err.Error(), "no places")
+}
+
+func Test_Should_Normalize_Names(t *testing.T) {
+	assert.Equal(t, "st johns", normalize(" St. John's "))
+	assert.Equal(t, "winston salem", normalize("Winston-Salem"))
+	assert.Equal(t, "zurich", normalize("Zürich"))
+}
+
+func Test_Should_Count_Edit_Distance_With_Transpositions(t *testing.T) {
+	assert.Equal(t, 0, editDistance("sydney", "sydney", 3))
+	assert.Equal(t, 1, editDistance("sydeny", "sydney", 3))
+	assert.Equal(t, 1, editDistance("melborne", "melbourne", 3))
+	assert.Equal(t, 3, editDistance("paris", "london", 3))
+}
+
+func Test_Should_Convert_GeoNames_Dump(t *testing.T) {
+	dump := strings.Join([]string{
+		"1", "Tombouctou", "Tombouctou", "Timbuktu,Tombouktou,Тимбукту,TOMBOUCTOU", "16.77348", "-3.00742",
+		"P", "PPLA", "ML", "", "", "", "", "", "35330", "", "264", "Africa/Bamako", "2020-01-01",
+	}, "\t") + "\n"
+	var converted strings.Builder
+	assert.NoError(t, ConvertGeoNames(strings.NewReader(dump), &converted))
+	assert.Equal(t, "geonameid,name,country_code,latitude,longitude,population,alternate_names\n"+
+		"1,Tombouctou,ML,16.77348,-3.00742,35330,Timbuktu|Tombouktou\n", converted.String())
+	g, err := NewGazetteer(strings.NewReader(converted.String()))
+	assert.NoError(t, err)
+	location, err := g.Resolve(weather.Location{City: "timbuktu"})
+	assert.NoError(t, err)
+	assert.Equal(t, "geonames:1", location.ID)
+}
+
+func Test_Should_Return_Error_For_Invalid_GeoNames_Dump(t *testing.T) {
+	err := ConvertGeoNames(strings.NewReader("1\tSydney\n"), &strings.Builder{})
+	assert.Contains(t, err.Error(), "geonames line 1 has 2 columns")
+}
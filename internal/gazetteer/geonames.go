@@ -0,0 +1,69 @@
+package gazetteer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// geonamesColumns is the number of tab separated columns of the GeoNames cities dumps, e.g. cities15000.txt.
+const geonamesColumns = 19
+
+// ConvertGeoNames converts a GeoNames cities dump, e.g. cities15000.txt, to the CSV of the embedded dataset.
+// Alternate names are kept when they are spelled in latin letters, which are the ones users type in queries.
+func ConvertGeoNames(src io.Reader, dst io.Writer) error {
+	writer := csv.NewWriter(dst)
+	if err := writer.Write([]string{
+		"geonameid", "name", "country_code", "latitude", "longitude", "population", "alternate_names",
+	}); err != nil {
+		return errors.Wrap(err, "failed to write gazetteer header")
+	}
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		columns := strings.Split(scanner.Text(), "\t")
+		if len(columns) != geonamesColumns {
+			return errors.Errorf("geonames line %v has %v columns, expected %v", line, len(columns), geonamesColumns)
+		}
+		name := columns[1]
+		record := []string{
+			columns[0], name, columns[8], columns[4], columns[5], columns[14],
+			strings.Join(latinAliases(name, append([]string{columns[2]}, strings.Split(columns[3], ",")...)), "|"),
+		}
+		if err := writer.Write(record); err != nil {
+			return errors.Wrapf(err, "failed to write gazetteer line %v", line+1)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read geonames dump")
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "failed to write gazetteer")
+}
+
+// latinAliases returns the aliases spelled in latin letters that normalize differently from the name and
+// from each other.
+func latinAliases(name string, aliases []string) []string {
+	seen := map[string]bool{normalize(name): true}
+	var latin []string
+	for _, alias := range aliases {
+		key := normalize(alias)
+		if key == "" || seen[key] || !isASCII(key) || strings.Contains(alias, "|") {
+			continue
+		}
+		seen[key] = true
+		latin = append(latin, alias)
+	}
+	return latin
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > 127 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package gazetteer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// diacritics folds latin letters with diacritics, so that "São Paulo" and "Sao Paulo" match.
+var diacritics = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ā", "a", "ą", "a", "æ", "ae",
+	"ç", "c", "ć", "c", "č", "c", "ď", "d", "đ", "d", "ð", "d",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ē", "e", "ę", "e", "ě", "e", "ğ", "g",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i", "ł", "l",
+	"ñ", "n", "ń", "n", "ň", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ō", "o", "œ", "oe",
+	"ŕ", "r", "ř", "r", "ś", "s", "š", "s", "ş", "s", "ß", "ss", "ť", "t", "ţ", "t", "þ", "th",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ý", "y", "ÿ", "y", "ź", "z", "ż", "z", "ž", "z",
+)
+
+// normalize lowercases the name, folds diacritics, drops dots and apostrophes,
+// and turns any other separators into single spaces, e.g. "St. John's" into "st johns".
+func normalize(name string) string {
+	folded := diacritics.Replace(strings.ToLower(name))
+	var builder strings.Builder
+	space := false
+	for _, r := range folded {
+		switch {
+		case r == '.' || r == '\'' || r == '’':
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && builder.Len() > 0 {
+				builder.WriteByte(' ')
+			}
+			space = false
+			builder.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return builder.String()
+}
+
+// allowedDistance is the number of typos allowed in a name, short names allow fewer not to match other cities.
+func allowedDistance(name string) int {
+	length := len([]rune(name))
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the optimal string alignment distance of the strings, counting insertions, deletions,
+// substitutions and transpositions of adjacent letters. Distances of at least limit are reported as limit.
+func editDistance(a string, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if abs(len(s)-len(t)) >= limit {
+		return limit
+	}
+	previous2 := make([]int, len(t)+1)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return min(previous[len(t)], limit)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"weather-reporter/internal/weather"
+)
+
+// maxBatchBodySize limits the size of a batch request body.
+const maxBatchBodySize = 1 << 20
+
+type BatchWeatherRequest struct {
+	Locations []weather.Location
+	// Invalid maps the malformed cities, which are not looked up, to the detail of their error.
+	Invalid map[string]string
+	Units   weather.Units
+	// Precision is the number of decimal places of measurements.
+	Precision int
+}
+
+type BatchWeatherHandler func(context.Context, BatchWeatherRequest) (interface{}, error)
+
+// CreateBatchWeatherHttpRouter creates a router accepting a json body like {"cities": ["sydney", "Sydney, CA"]}
+// with at most maxCities cities.
+func CreateBatchWeatherHttpRouter(handler BatchWeatherHandler, maxCities int) Router {
+	return Router{
+		Method: "POST",
+		Path:   "/v1/weather:batch",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleBatchWeatherRequest(w, r, handler, maxCities)
+		}),
+		Summary:    "Current weather of many cities, keyed by city",
+		Parameters: renderingParameters(),
+		Body: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"cities": arraySchema(&Schema{Type: "string"}, 1, maxCities),
+			},
+			Required: []string{"cities"},
+		},
+		Response: map[string]weather.BatchResult{},
+	}
+}
+
+type batchWeatherBody struct {
+	Cities []string `json:"cities"`
+}
+
+func handleBatchWeatherRequest(writer http.ResponseWriter, request *http.Request, handler BatchWeatherHandler, maxCities int) {
+	units, precision, err := parseRendering(request.URL.Query())
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	var body batchWeatherBody
+	err = json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxBatchBodySize)).Decode(&body)
+	if err != nil {
+		sendBadRequestResponse(writer, errors.Wrap(err, "failed to unmarshal json body"))
+		return
+	}
+	if len(body.Cities) == 0 || len(body.Cities) > maxCities {
+		sendBadRequestResponse(writer, errors.Errorf("cities must have from 1 to %v cities", maxCities))
+		return
+	}
+	// a malformed city is reported in its own result, like a failed lookup, not to fail the other cities
+	locations := make([]weather.Location, 0, len(body.Cities))
+	invalid := make(map[string]string)
+	for _, city := range body.Cities {
+		location, err := weather.ParseCityLocation(city)
+		if err != nil {
+			invalid[city] = weather.ErrorDetail(err)
+			continue
+		}
+		locations = append(locations, location)
+	}
+	e, ok := negotiate(writer, request)
+	if !ok {
+		return
+	}
+	data, err := handler(request.Context(), BatchWeatherRequest{
+		Locations: locations,
+		Invalid:   invalid,
+		Units:     units,
+		Precision: precision,
+	})
+	if err != nil {
+		sendHandlerError(writer, err)
+		return
+	}
+	sendResponse(writer, request, e, data)
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"weather-reporter/internal/weather"
+)
+
+func Test_Should_Report_Malformed_City_In_Its_Own_Result(t *testing.T) {
+	var request BatchWeatherRequest
+	router := CreateBatchWeatherHttpRouter(func(ctx context.Context, r BatchWeatherRequest) (interface{}, error) {
+		request = r
+		return map[string]weather.BatchResult{}, nil
+	}, 10)
+	recorder := serveRoot(router.Handler, "POST", "/v1/weather:batch", `{"cities": ["sydney", "sydney!", "Sydney, AUS"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []weather.Location{{City: "sydney"}}, request.Locations)
+	assert.Equal(t, map[string]string{
+		"sydney!":     `city must have up to 100 letters, spaces, dots, apostrophes and hyphens`,
+		"Sydney, AUS": `country must be a two-letter ISO 3166-1 code, got "AUS"`,
+	}, request.Invalid)
+}
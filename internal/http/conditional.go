@@ -0,0 +1,54 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// entityTag derives a strong entity tag from the response body, so that identical representations
+// share a tag whether they were served from the cache or fetched from a provider.
+func entityTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// isNotModified tells whether the client already has the representation with the entity tag and the
+// Last-Modified header set on the response. If-None-Match takes precedence over If-Modified-Since as in RFC 7232.
+func isNotModified(request *http.Request, header http.Header, etag string) bool {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return matchesEntityTag(ifNoneMatch, etag)
+	}
+	ifModifiedSince, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ifModifiedSince)
+}
+
+// matchesEntityTag compares the tags of an If-None-Match header with the weak comparison.
+func matchesEntityTag(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setLastModified sets the Last-Modified header to the time, which is not set when unknown.
+func setLastModified(header http.Header, at *time.Time) {
+	if at != nil {
+		header.Set("Last-Modified", at.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+func serveConditionalWeather(handler WeatherHandler, header string, value string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/v1/weather?city=sydney", nil)
+	if header != "" {
+		request.Header.Set(header, value)
+	}
+	CreateWeatherHttpRouter(handler).Handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_Should_Tag_Same_Weather_With_Same_ETag(t *testing.T) {
+	fetched := serveConditionalWeather(metadataHandler(weather.Metadata{MaxAge: time.Second}), "", "")
+	cached := serveConditionalWeather(metadataHandler(weather.Metadata{Age: time.Second}), "", "")
+	stale := serveConditionalWeather(metadataHandler(weather.Metadata{Age: time.Minute, Stale: true}), "", "")
+	etag := fetched.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, cached.Header().Get("ETag"))
+	assert.Equal(t, etag, stale.Header().Get("ETag"))
+}
+
+func Test_Should_Tag_Different_Weather_With_Different_ETags(t *testing.T) {
+	recorder := serveConditionalWeather(metadataHandler(weather.Metadata{}), "", "")
+	other := serveWeather(func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		return weather.Weather{TemperatureDegrees: 2}, weather.Metadata{}, nil
+	}, "city=sydney")
+	assert.NotEqual(t, recorder.Header().Get("ETag"), other.Header().Get("ETag"))
+}
+
+func Test_Should_Respond_Not_Modified_When_ETag_Matches(t *testing.T) {
+	etag := serveConditionalWeather(metadataHandler(weather.Metadata{}), "", "").Header().Get("ETag")
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		recorder := serveConditionalWeather(metadataHandler(weather.Metadata{}), "If-None-Match", ifNoneMatch)
+		assert.Equal(t, http.StatusNotModified, recorder.Code, ifNoneMatch)
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		assert.Empty(t, recorder.Body.String())
+	}
+}
+
+func Test_Should_Respond_With_Weather_When_ETag_Differs(t *testing.T) {
+	recorder := serveConditionalWeather(metadataHandler(weather.Metadata{}), "If-None-Match", `"other"`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEmpty(t, recorder.Body.String())
+}
+
+func Test_Should_Set_Last_Modified_From_Observation_Time(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))
+	recorder := serveConditionalWeather(metadataHandler(weather.Metadata{ObservedAt: &observedAt}), "", "")
+	assert.Equal(t, "Wed, 02 Jan 2019 02:04:05 GMT", recorder.Header().Get("Last-Modified"))
+
+	recorder = serveConditionalWeather(metadataHandler(weather.Metadata{}), "", "")
+	assert.Empty(t, recorder.Header().Get("Last-Modified"))
+}
+
+func Test_Should_Respond_Not_Modified_When_Not_Observed_Since(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := metadataHandler(weather.Metadata{ObservedAt: &observedAt})
+	recorder := serveConditionalWeather(handler, "If-Modified-Since", "Wed, 02 Jan 2019 03:04:05 GMT")
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = serveConditionalWeather(handler, "If-Modified-Since", "Wed, 02 Jan 2019 03:04:04 GMT")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func Test_Should_Prefer_ETag_Over_Modification_Time(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/v1/weather?city=sydney", nil)
+	request.Header.Set("If-None-Match", `"other"`)
+	request.Header.Set("If-Modified-Since", "Wed, 02 Jan 2019 03:04:05 GMT")
+	CreateWeatherHttpRouter(metadataHandler(weather.Metadata{ObservedAt: &observedAt})).Handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,327 @@
+package http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xmlName matches keys that can be used as XML element names, other keys are written as entry elements.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// encodeXml writes objects as elements named by their keys and arrays as item elements, under a response element.
+func encodeXml(data interface{}) ([]byte, error) {
+	tree, err := toTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buffer)
+	if err := writeXmlElement(encoder, xml.StartElement{Name: xml.Name{Local: "response"}}, tree); err != nil {
+		return nil, errors.Wrap(err, "failed to write xml")
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, errors.Wrap(err, "failed to write xml")
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeXmlElement(encoder *xml.Encoder, start xml.StartElement, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			element := xml.StartElement{Name: xml.Name{Local: f.key}}
+			if !xmlName.MatchString(f.key) || strings.HasPrefix(strings.ToLower(f.key), "xml") {
+				element = xml.StartElement{
+					Name: xml.Name{Local: "entry"},
+					Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: f.key}},
+				}
+			}
+			if err := writeXmlElement(encoder, element, f.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXmlElement(encoder, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(formatScalar(v))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// encodeCsv writes the records of the data with a header of their keys.
+func encodeCsv(data interface{}) ([]byte, error) {
+	tree, err := toTree(data)
+	if err != nil {
+		return nil, err
+	}
+	rows := records(tree)
+	var header []string
+	columns := make(map[string]int)
+	for _, row := range rows {
+		for _, f := range row {
+			if _, ok := columns[f.key]; !ok {
+				columns[f.key] = len(header)
+				header = append(header, f.key)
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(header); err != nil {
+		return nil, errors.Wrap(err, "failed to write csv")
+	}
+	for _, row := range rows {
+		line := make([]string, len(header))
+		for _, f := range row {
+			line[columns[f.key]] = formatScalar(f.value)
+		}
+		if err := writer.Write(line); err != nil {
+			return nil, errors.Wrap(err, "failed to write csv")
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), errors.Wrap(writer.Error(), "failed to write csv")
+}
+
+// encodeText writes a line of key=value pairs per record of the data, to be read in a terminal.
+func encodeText(data interface{}) ([]byte, error) {
+	tree, err := toTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	for _, row := range records(tree) {
+		pairs := make([]string, len(row))
+		for i, f := range row {
+			value := formatScalar(f.value)
+			if value == "" || strings.ContainsAny(value, " \"=") {
+				value = strconv.Quote(value)
+			}
+			pairs[i] = f.key + "=" + value
+		}
+		buffer.WriteString(strings.Join(pairs, " "))
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
+
+// records flattens the tree to records of scalars keyed by their dotted paths, e.g. "units.temperature".
+// A root array has a record per element, a root object with an array of objects, like the days of a forecast,
+// has a record per element of the array along with the other fields of the object, any other tree is a record.
+func records(tree interface{}) []object {
+	switch v := tree.(type) {
+	case []interface{}:
+		rows := make([]object, len(v))
+		for i, item := range v {
+			rows[i] = flatten("", item, object{})
+		}
+		return rows
+	case object:
+		for i, f := range v {
+			items, ok := f.value.([]interface{})
+			if !ok || len(items) == 0 || !allObjects(items) {
+				continue
+			}
+			var common object
+			for j, other := range v {
+				if j != i {
+					common = flatten(other.key, other.value, common)
+				}
+			}
+			rows := make([]object, len(items))
+			for j, item := range items {
+				rows[j] = flatten("", item, append(object{}, common...))
+			}
+			return rows
+		}
+	}
+	return []object{flatten("", tree, object{})}
+}
+
+func allObjects(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := value.(object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func flatten(prefix string, value interface{}, record object) object {
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			record = flatten(joinKey(prefix, f.key), f.value, record)
+		}
+	case []interface{}:
+		for i, item := range v {
+			record = flatten(joinKey(prefix, strconv.Itoa(i)), item, record)
+		}
+	default:
+		if prefix == "" {
+			prefix = "value"
+		}
+		record = append(record, field{key: prefix, value: v})
+	}
+	return record
+}
+
+func joinKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+// encodeMessagePack writes the data in the MessagePack format, see https://github.com/msgpack/msgpack/blob/master/spec.md.
+func encodeMessagePack(data interface{}) ([]byte, error) {
+	tree, err := toTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := writeMessagePack(&buffer, tree); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeMessagePack(buffer *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteByte(0xc0)
+	case bool:
+		if v {
+			buffer.WriteByte(0xc3)
+		} else {
+			buffer.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMessagePackInt(buffer, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert %v to number", v)
+		}
+		buffer.WriteByte(0xcb)
+		writeBigEndian(buffer, math.Float64bits(f), 8)
+	case string:
+		writeMessagePackHeader(buffer, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buffer.WriteString(v)
+	case []interface{}:
+		writeMessagePackHeader(buffer, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMessagePack(buffer, item); err != nil {
+				return err
+			}
+		}
+	case object:
+		writeMessagePackHeader(buffer, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, f := range v {
+			if err := writeMessagePack(buffer, f.key); err != nil {
+				return err
+			}
+			if err := writeMessagePack(buffer, f.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unexpected value %v", v)
+	}
+	return nil
+}
+
+func writeMessagePackInt(buffer *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buffer.WriteByte(byte(i))
+	case i >= -32 && i < 0:
+		buffer.WriteByte(byte(0xe0 | (i + 32)))
+	case i >= 0 && i <= math.MaxUint8:
+		buffer.WriteByte(0xcc)
+		writeBigEndian(buffer, uint64(i), 1)
+	case i >= 0 && i <= math.MaxUint16:
+		buffer.WriteByte(0xcd)
+		writeBigEndian(buffer, uint64(i), 2)
+	case i >= 0 && i <= math.MaxUint32:
+		buffer.WriteByte(0xce)
+		writeBigEndian(buffer, uint64(i), 4)
+	case i >= 0:
+		buffer.WriteByte(0xcf)
+		writeBigEndian(buffer, uint64(i), 8)
+	case i >= math.MinInt8:
+		buffer.WriteByte(0xd0)
+		writeBigEndian(buffer, uint64(i), 1)
+	case i >= math.MinInt16:
+		buffer.WriteByte(0xd1)
+		writeBigEndian(buffer, uint64(i), 2)
+	case i >= math.MinInt32:
+		buffer.WriteByte(0xd2)
+		writeBigEndian(buffer, uint64(i), 4)
+	default:
+		buffer.WriteByte(0xd3)
+		writeBigEndian(buffer, uint64(i), 8)
+	}
+}
+
+// writeMessagePackHeader writes the length of a string, array or map in the smallest format:
+// fixed with the length below fixedLimit, 8, 16 or 32 bits. Arrays and maps have no 8 bit format.
+func writeMessagePackHeader(buffer *bytes.Buffer, length int, fixed byte, fixedLimit int, format8 byte, format16 byte, format32 byte) {
+	switch {
+	case length < fixedLimit:
+		buffer.WriteByte(fixed | byte(length))
+	case format8 != 0 && length <= math.MaxUint8:
+		buffer.WriteByte(format8)
+		writeBigEndian(buffer, uint64(length), 1)
+	case length <= math.MaxUint16:
+		buffer.WriteByte(format16)
+		writeBigEndian(buffer, uint64(length), 2)
+	default:
+		buffer.WriteByte(format32)
+		writeBigEndian(buffer, uint64(length), 4)
+	}
+}
+
+func writeBigEndian(buffer *bytes.Buffer, value uint64, size int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], value)
+	buffer.Write(b[8-size:])
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"weather-reporter/internal/weather"
+)
+
+func testWeather() weather.Weather {
+	gust := 30.5
+	return weather.Weather{
+		WindSpeed:          20,
+		TemperatureDegrees: 29,
+		Units:              weather.Units{WindSpeed: weather.KilometresPerHour, Temperature: weather.Celsius},
+		WindGust:           &gust,
+		Condition:          &weather.Condition{Code: "800", Description: "clear sky"},
+		Provider:           "yahoo",
+	}
+}
+
+func testForecast() weather.Forecast {
+	return weather.Forecast{
+		Days: []weather.DailyForecast{
+			{Date: "2019-01-02", TemperatureMin: 18, TemperatureMax: 29},
+			{Date: "2019-01-03", TemperatureMin: 19, TemperatureMax: 31},
+		},
+		Units:    weather.Units{WindSpeed: weather.KilometresPerHour, Temperature: weather.Celsius},
+		Provider: "yahoo",
+	}
+}
+
+func Test_Should_Encode_Json(t *testing.T) {
+	body, err := encoders[0].encode(testWeather())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"wind_speed": 20, "temperature_degrees": 29, "units": {"wind_speed": "km/h", "temperature": "celsius"},
+		"wind_gust": 30.5, "condition": {"code": "800", "description": "clear sky"}, "provider": "yahoo"}`, string(body))
+}
+
+func Test_Should_Encode_Xml(t *testing.T) {
+	body, err := encodeXml(testWeather())
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><wind_speed>20</wind_speed><temperature_degrees>29</temperature_degrees>`+
+		`<units><wind_speed>km/h</wind_speed><temperature>celsius</temperature></units><wind_gust>30.5</wind_gust>`+
+		`<condition><code>800</code><description>clear sky</description></condition><provider>yahoo</provider></response>`,
+		string(body))
+}
+
+func Test_Should_Encode_Xml_Arrays_And_Keys_Which_Are_Not_Names(t *testing.T) {
+	body, err := encodeXml(map[string][]int{"Sydney, AU": {1, 2}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `<response><entry key="Sydney, AU"><item>1</item><item>2</item></entry></response>`)
+}
+
+func Test_Should_Encode_Csv(t *testing.T) {
+	body, err := encodeCsv(testWeather())
+	assert.NoError(t, err)
+	assert.Equal(t, "wind_speed,temperature_degrees,units.wind_speed,units.temperature,wind_gust,"+
+		"condition.code,condition.description,provider\n"+
+		"20,29,km/h,celsius,30.5,800,clear sky,yahoo\n", string(body))
+}
+
+func Test_Should_Encode_Csv_Record_Per_Day(t *testing.T) {
+	body, err := encodeCsv(testForecast())
+	assert.NoError(t, err)
+	assert.Equal(t, "units.wind_speed,units.temperature,provider,date,temperature_min,temperature_max\n"+
+		"km/h,celsius,yahoo,2019-01-02,18,29\n"+
+		"km/h,celsius,yahoo,2019-01-03,19,31\n", string(body))
+}
+
+func Test_Should_Encode_Csv_Record_Per_Element(t *testing.T) {
+	body, err := encodeCsv([]map[string]string{{"name": "Sydney"}, {"name": "Sydney, NS"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "name\nSydney\n\"Sydney, NS\"\n", string(body))
+}
+
+func Test_Should_Encode_Text(t *testing.T) {
+	body, err := encodeText(testWeather())
+	assert.NoError(t, err)
+	assert.Equal(t, "wind_speed=20 temperature_degrees=29 units.wind_speed=km/h units.temperature=celsius wind_gust=30.5 "+
+		"condition.code=800 condition.description=\"clear sky\" provider=yahoo\n", string(body))
+}
+
+func Test_Should_Encode_Text_Line_Per_Day(t *testing.T) {
+	body, err := encodeText(testForecast())
+	assert.NoError(t, err)
+	assert.Equal(t, "units.wind_speed=km/h units.temperature=celsius provider=yahoo date=2019-01-02 temperature_min=18 temperature_max=29\n"+
+		"units.wind_speed=km/h units.temperature=celsius provider=yahoo date=2019-01-03 temperature_min=19 temperature_max=31\n",
+		string(body))
+}
+
+func Test_Should_Encode_Message_Pack(t *testing.T) {
+	body, err := encodeMessagePack(map[string]interface{}{
+		"a": []interface{}{nil, true, false, 1, -1, 200, -200, 70000, 1.5, "x"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x81, 0xa1, 'a', 0x9a,
+		0xc0, 0xc3, 0xc2, 0x01, 0xff,
+		0xcc, 200,
+		0xd1, 0xff, 0x38,
+		0xce, 0x00, 0x01, 0x11, 0x70,
+		0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		0xa1, 'x',
+	}, body)
+}
+
+func Test_Should_Encode_Long_Message_Pack_Strings_And_Arrays(t *testing.T) {
+	long := make([]byte, 40)
+	for i := range long {
+		long[i] = 'x'
+	}
+	body, err := encodeMessagePack(string(long))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xd9, 40}, long...), body)
+
+	body, err = encodeMessagePack(make([]int, 20))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xdc, 0, 20}, make([]byte, 20)...), body)
+}
@@ -0,0 +1,238 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encoder writes responses in a representation requested by the format query parameter
+// or by the Accept header with any of its media types.
+type encoder struct {
+	format     string
+	mediaTypes []string
+	// contentType is the Content-Type header of responses, the first media type when empty.
+	contentType string
+	encode      func(data interface{}) ([]byte, error)
+}
+
+func (e encoder) ContentType() string {
+	if e.contentType != "" {
+		return e.contentType
+	}
+	return e.mediaTypes[0]
+}
+
+// encoders are the supported representations, the first one is used when the client has no preference.
+var encoders = []encoder{
+	{format: "json", mediaTypes: []string{"application/json"}, encode: json.Marshal},
+	{format: "xml", mediaTypes: []string{"application/xml", "text/xml"}, encode: encodeXml},
+	{format: "csv", mediaTypes: []string{"text/csv"}, contentType: "text/csv; charset=utf-8", encode: encodeCsv},
+	{format: "msgpack", mediaTypes: []string{"application/msgpack", "application/x-msgpack"}, encode: encodeMessagePack},
+	{format: "text", mediaTypes: []string{"text/plain"}, contentType: "text/plain; charset=utf-8", encode: encodeText},
+}
+
+// negotiateEncoder picks the encoder of the format query parameter, or the one of the most preferred media type
+// of the Accept header.
+func negotiateEncoder(request *http.Request) (encoder, error) {
+	if format := request.URL.Query().Get("format"); format != "" {
+		for _, e := range encoders {
+			if e.format == format {
+				return e, nil
+			}
+		}
+		return encoder{}, errors.Errorf("format must be one of %v", strings.Join(supportedFormats(), ", "))
+	}
+	accept := request.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0], nil
+	}
+	ranges, refused := parseAccept(accept)
+	for _, r := range ranges {
+		for _, e := range encoders {
+			if e.matches(r) && !e.refused(refused) {
+				return e, nil
+			}
+		}
+	}
+	return encoder{}, errors.Errorf("none of the accepted media types is supported, accept one of %v",
+		strings.Join(supportedMediaTypes(), ", "))
+}
+
+func (e encoder) matches(mediaRange string) bool {
+	for _, mediaType := range e.mediaTypes {
+		if mediaRange == "*/*" || mediaRange == mediaType ||
+			(strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e encoder) refused(mediaTypes []string) bool {
+	for _, mediaType := range mediaTypes {
+		for _, m := range e.mediaTypes {
+			if m == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseAccept returns the media ranges of an Accept header, the most preferred first,
+// along with the media types refused with zero quality.
+func parseAccept(accept string) ([]string, []string) {
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+	var ranges []mediaRange
+	var refused []string
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				quality, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err == nil {
+					r.quality = quality
+				}
+			}
+		}
+		if r.mediaType == "" {
+			continue
+		}
+		if r.quality <= 0 {
+			refused = append(refused, r.mediaType)
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	mediaTypes := make([]string, len(ranges))
+	for i, r := range ranges {
+		mediaTypes[i] = r.mediaType
+	}
+	return mediaTypes, refused
+}
+
+func supportedFormats() []string {
+	formats := make([]string, len(encoders))
+	for i, e := range encoders {
+		formats[i] = e.format
+	}
+	return formats
+}
+
+func supportedMediaTypes() []string {
+	var mediaTypes []string
+	for _, e := range encoders {
+		mediaTypes = append(mediaTypes, e.mediaTypes...)
+	}
+	return mediaTypes
+}
+
+// negotiate picks the encoder of the request before it is handled, so that the lookup is spared
+// when the response is not acceptable, answering 406 Not Acceptable when there is none.
+func negotiate(writer http.ResponseWriter, request *http.Request) (encoder, bool) {
+	writer.Header().Add("Vary", "Accept")
+	e, err := negotiateEncoder(request)
+	if err != nil {
+		log.WithField("error", err).Debug("not acceptable")
+		writeErrorResponse(writer, http.StatusNotAcceptable, err.Error())
+		return encoder{}, false
+	}
+	return e, true
+}
+
+// sendResponse sends the data in the negotiated representation tagged with an ETag,
+// or only 304 Not Modified when the client already has it.
+func sendResponse(writer http.ResponseWriter, request *http.Request, e encoder, data interface{}) {
+	response, err := e.encode(data)
+	if err != nil {
+		sendErrorResponse(writer, errors.Wrapf(err, "failed to encode %v", e.format))
+		return
+	}
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		etag := entityTag(response)
+		writer.Header().Set("ETag", etag)
+		if isNotModified(request, writer.Header(), etag) {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	writer.Header().Set("Content-Type", e.ContentType())
+	_, err = writer.Write(response)
+	if err != nil {
+		err = errors.Wrap(err, "failed to write response")
+		log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+	}
+}
+
+// field is a member of an object of a document tree.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object keeps the members of a JSON object in order, so that other representations list them as JSON does.
+type object []field
+
+// toTree converts the data to the tree of its JSON document made of objects, []interface{} arrays,
+// json.Number numbers, strings, booleans and nils, so that every representation follows the JSON field names.
+func toTree(data interface{}) (interface{}, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal json")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decodeTree(decoder)
+}
+
+func decodeTree(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode json")
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	if delim == '{' {
+		o := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to decode json")
+			}
+			value, err := decodeTree(decoder)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, field{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return o, errors.Wrap(err, "failed to decode json")
+	}
+	values := []interface{}{}
+	for decoder.More() {
+		value, err := decodeTree(decoder)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	_, err = decoder.Token()
+	return values, errors.Wrap(err, "failed to decode json")
+}
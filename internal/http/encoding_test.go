@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"weather-reporter/internal/weather"
+)
+
+func serveWeatherAccepting(query string, accept string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/v1/weather?city=sydney"+query, nil)
+	request.Header.Set("Accept", accept)
+	CreateWeatherHttpRouter(metadataHandler(weather.Metadata{})).Handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_Should_Negotiate_Content_Type_From_Accept_Header(t *testing.T) {
+	for accept, contentType := range map[string]string{
+		"":                                      "application/json",
+		"*/*":                                   "application/json",
+		"application/xml":                       "application/xml",
+		"text/xml":                              "application/xml",
+		"text/csv":                              "text/csv; charset=utf-8",
+		"application/x-msgpack":                 "application/msgpack",
+		"text/plain":                            "text/plain; charset=utf-8",
+		"text/*":                                "application/xml",
+		"text/html, text/csv;q=0.5, text/plain": "text/plain; charset=utf-8",
+		"application/json;q=0, application/*":   "application/xml",
+		"text/html;q=0.9, application/msgpack;q=1": "application/msgpack",
+	} {
+		recorder := serveWeatherAccepting("", accept)
+		assert.Equal(t, http.StatusOK, recorder.Code, accept)
+		assert.Equal(t, contentType, recorder.Header().Get("Content-Type"), accept)
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"), accept)
+	}
+}
+
+func Test_Should_Prefer_Format_Parameter_Over_Accept_Header(t *testing.T) {
+	recorder := serveWeatherAccepting("&format=csv", "application/json")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+}
+
+func Test_Should_Respond_Not_Acceptable_For_Unsupported_Types(t *testing.T) {
+	for query, accept := range map[string]string{"": "text/html", "&format=yaml": "", "&x=1": "application/json;q=0"} {
+		recorder := serveWeatherAccepting(query, accept)
+		assert.Equal(t, http.StatusNotAcceptable, recorder.Code, accept)
+		assert.NotEmpty(t, decodeProblem(t, recorder).Detail, accept)
+	}
+}
+
+func Test_Should_Not_Call_Handler_When_Response_Is_Not_Acceptable(t *testing.T) {
+	handler := func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		t.Fatal("handler must not be called")
+		return nil, weather.Metadata{}, nil
+	}
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/v1/weather?city=sydney", nil)
+	request.Header.Set("Accept", "text/html")
+	CreateWeatherHttpRouter(handler).Handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+}
+
+func Test_Should_Tag_Representations_With_Different_ETags(t *testing.T) {
+	json := serveWeatherAccepting("", "application/json")
+	xml := serveWeatherAccepting("", "application/xml")
+	assert.NotEqual(t, json.Header().Get("ETag"), xml.Header().Get("ETag"))
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"weather-reporter/internal/weather"
+)
+
+type ForecastRequest struct {
+	Location weather.Location
+	Days     int
+	Units    weather.Units
+	// Precision is the number of decimal places of measurements.
+	Precision int
+}
+
+type ForecastHandler func(context.Context, ForecastRequest) (interface{}, weather.Metadata, error)
+
+func CreateForecastHttpRouter(handler ForecastHandler) Router {
+	return Router{
+		Method: "GET",
+		Path:   "/v1/forecast",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleForecastRequest(w, r, handler)
+		}),
+		Summary: "Daily forecast of a city or coordinates",
+		Parameters: append(append(locationParameters(), renderingParameters()...), envelopeParameter(),
+			queryParameter("days", "The number of days to forecast.", integerSchema(1, weather.MaxForecastDays))),
+		Response: weather.Forecast{},
+	}
+}
+
+func handleForecastRequest(writer http.ResponseWriter, request *http.Request, handler ForecastHandler) {
+	days, err := parseCount(request.URL.Query(), "days", weather.MaxForecastDays, weather.MaxForecastDays)
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	handleResourceRequest(writer, request, func(ctx context.Context, location weather.Location, units weather.Units,
+		precision int) (interface{}, weather.Metadata, error) {
+		return handler(ctx, ForecastRequest{
+			Location:  location,
+			Days:      days,
+			Units:     units,
+			Precision: precision,
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"weather-reporter/internal/weather"
+)
+
+type HourlyForecastRequest struct {
+	Location weather.Location
+	Hours    int
+	Units    weather.Units
+	// Precision is the number of decimal places of measurements.
+	Precision int
+}
+
+type HourlyForecastHandler func(context.Context, HourlyForecastRequest) (interface{}, weather.Metadata, error)
+
+func CreateHourlyForecastHttpRouter(handler HourlyForecastHandler) Router {
+	return Router{
+		Method: "GET",
+		Path:   "/v1/forecast/hourly",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleHourlyForecastRequest(w, r, handler)
+		}),
+		Summary: "Hourly forecast of a city or coordinates, starting from the current hour",
+		Parameters: append(append(locationParameters(), renderingParameters()...), envelopeParameter(),
+			queryParameter("hours", "The number of hours to forecast.", integerSchema(1, weather.MaxForecastHours))),
+		Response: weather.HourlyForecast{},
+	}
+}
+
+func handleHourlyForecastRequest(writer http.ResponseWriter, request *http.Request, handler HourlyForecastHandler) {
+	hours, err := parseCount(request.URL.Query(), "hours", weather.MaxForecastHours, weather.MaxForecastHours)
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	handleResourceRequest(writer, request, func(ctx context.Context, location weather.Location, units weather.Units,
+		precision int) (interface{}, weather.Metadata, error) {
+		return handler(ctx, HourlyForecastRequest{
+			Location:  location,
+			Hours:     hours,
+			Units:     units,
+			Precision: precision,
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"weather-reporter/internal/gazetteer"
+)
+
+const (
+	defaultLocationsLimit = 10
+	maxLocationsLimit     = 50
+	maxLocationsQuery     = 100
+)
+
+type LocationsRequest struct {
+	Query string
+	Limit int
+}
+
+type LocationsHandler func(context.Context, LocationsRequest) (interface{}, error)
+
+// CreateLocationsHttpRouter creates a router autocompleting place names, e.g. /v1/locations?q=syd.
+func CreateLocationsHttpRouter(handler LocationsHandler) Router {
+	return Router{
+		Method: "GET",
+		Path:   "/v1/locations",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleLocationsRequest(w, r, handler)
+		}),
+		Summary: "Places whose name starts with the query, the most populated first",
+		Parameters: []Parameter{
+			{Name: "q", In: "query", Description: "The beginning of the place name.", Required: true,
+				Schema: stringSchema(1, maxLocationsQuery)},
+			queryParameter("limit", "The maximum number of places.", integerSchema(1, maxLocationsLimit)),
+			queryParameter("format", "The representation of the response, overriding the Accept header.",
+				enumSchema(supportedFormats())),
+		},
+		Response: []gazetteer.Place{},
+	}
+}
+
+func handleLocationsRequest(writer http.ResponseWriter, request *http.Request, handler LocationsHandler) {
+	query := request.URL.Query()
+	q := query.Get("q")
+	if q == "" || len(q) > maxLocationsQuery {
+		sendBadRequestResponse(writer, errors.Errorf("q must have from 1 to %v characters", maxLocationsQuery))
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	e, ok := negotiate(writer, request)
+	if !ok {
+		return
+	}
+	data, err := handler(request.Context(), LocationsRequest{Query: q, Limit: limit})
+	if err != nil {
+		sendHandlerError(writer, err)
+		return
+	}
+	sendResponse(writer, request, e, data)
+}
+
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return defaultLocationsLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLocationsLimit {
+		return 0, errors.Errorf("limit must be an integer from 1 to %v", maxLocationsLimit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// envelope wraps a response with its metadata when requested by the envelope query parameter.
+type envelope struct {
+	Data     interface{}      `json:"data"`
+	Metadata responseMetadata `json:"metadata"`
+}
+
+type responseMetadata struct {
+	Provider   string     `json:"provider,omitempty"`
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+	// CacheAge is the time in seconds since the data was fetched from the provider.
+	CacheAge int  `json:"cache_age"`
+	Stale    bool `json:"stale"`
+}
+
+// parseEnvelope parses the envelope query parameter shared by all weather resources.
+func parseEnvelope(query url.Values) (bool, error) {
+	value := query.Get("envelope")
+	if value == "" {
+		return false, nil
+	}
+	enveloped, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Errorf("envelope must be true or false, got %q", value)
+	}
+	return enveloped, nil
+}
+
+// sendWithMetadata sends the data with caching and provider headers describing its metadata,
+// wrapped into an envelope with the metadata when requested.
+func sendWithMetadata(writer http.ResponseWriter, request *http.Request, e encoder, data interface{}, metadata weather.Metadata, enveloped bool) {
+	header := writer.Header()
+	setLastModified(header, metadata.ObservedAt)
+	header.Set("Age", strconv.Itoa(int(metadata.Age/time.Second)))
+	if metadata.Provider != "" {
+		header.Set("X-Weather-Provider", metadata.Provider)
+	}
+	if metadata.ObservedAt != nil {
+		header.Set("X-Weather-Observed-At", metadata.ObservedAt.UTC().Format(time.RFC3339))
+	}
+	maxAge := 0
+	if !metadata.Stale && metadata.MaxAge > 0 {
+		maxAge = int(metadata.MaxAge / time.Second)
+	}
+	header.Set("Cache-Control", "max-age="+strconv.Itoa(maxAge))
+	if metadata.Stale {
+		header.Add("Warning", `110 - "Response is Stale"`)
+		header.Add("Warning", `111 - "Revalidation Failed"`)
+	}
+	if enveloped {
+		data = envelope{
+			Data: data,
+			Metadata: responseMetadata{
+				Provider:   metadata.Provider,
+				ObservedAt: metadata.ObservedAt,
+				CacheAge:   int(metadata.Age / time.Second),
+				Stale:      metadata.Stale,
+			},
+		}
+	}
+	sendResponse(writer, request, e, data)
+}
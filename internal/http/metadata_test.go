@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+func metadataHandler(metadata weather.Metadata) WeatherHandler {
+	return func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		return weather.Weather{TemperatureDegrees: 1}, metadata, nil
+	}
+}
+
+func Test_Should_Set_Caching_Headers_From_Metadata(t *testing.T) {
+	metadata := weather.Metadata{Age: 90 * time.Second, MaxAge: 150 * time.Second}
+	recorder := serveWeather(metadataHandler(metadata), "city=sydney")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "90", recorder.Header().Get("Age"))
+	assert.Equal(t, "max-age=150", recorder.Header().Get("Cache-Control"))
+	assert.Empty(t, recorder.Header()["Warning"])
+}
+
+func Test_Should_Set_Provider_Headers_From_Metadata(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 14, 4, 5, 0, time.FixedZone("AEDT", 11*60*60))
+	metadata := weather.Metadata{Provider: "provider-1", ObservedAt: &observedAt}
+	recorder := serveWeather(metadataHandler(metadata), "city=sydney")
+	assert.Equal(t, "provider-1", recorder.Header().Get("X-Weather-Provider"))
+	assert.Equal(t, "2019-01-02T03:04:05Z", recorder.Header().Get("X-Weather-Observed-At"))
+}
+
+func Test_Should_Not_Set_Provider_Headers_Without_Metadata(t *testing.T) {
+	recorder := serveWeather(metadataHandler(weather.Metadata{}), "city=sydney")
+	assert.NotContains(t, recorder.Header(), "X-Weather-Provider")
+	assert.NotContains(t, recorder.Header(), "X-Weather-Observed-At")
+}
+
+func Test_Should_Warn_About_Stale_Response(t *testing.T) {
+	metadata := weather.Metadata{Age: time.Hour, Stale: true}
+	recorder := serveWeather(metadataHandler(metadata), "city=sydney")
+	assert.Equal(t, "3600", recorder.Header().Get("Age"))
+	assert.Equal(t, "max-age=0", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, []string{`110 - "Response is Stale"`, `111 - "Revalidation Failed"`}, recorder.Header()["Warning"])
+}
+
+func Test_Should_Wrap_Response_Into_Envelope(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	metadata := weather.Metadata{Provider: "provider-1", ObservedAt: &observedAt, Age: 61 * time.Second, Stale: true}
+	recorder := serveWeather(metadataHandler(metadata), "city=sydney&envelope=true")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var body map[string]map[string]interface{}
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, float64(1), body["data"]["temperature_degrees"])
+	assert.Equal(t, map[string]interface{}{
+		"provider":    "provider-1",
+		"observed_at": "2019-01-02T03:04:05Z",
+		"cache_age":   float64(61),
+		"stale":       true,
+	}, body["metadata"])
+}
+
+func Test_Should_Not_Wrap_Response_By_Default(t *testing.T) {
+	recorder := serveWeather(metadataHandler(weather.Metadata{Provider: "provider-1"}), "city=sydney")
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.NotContains(t, body, "metadata")
+	assert.Equal(t, float64(1), body["temperature_degrees"])
+}
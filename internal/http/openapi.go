@@ -0,0 +1,310 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// componentsPrefix is the prefix of references to schemas of the components of the specification.
+const componentsPrefix = "#/components/schemas/"
+
+// Parameter describes a query parameter of a route in the OpenAPI specification.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema is the subset of the OpenAPI schema object used to describe parameters, bodies and responses.
+type Schema struct {
+	Ref         string   `json:"$ref,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Nullable    bool     `json:"nullable,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	MinLength   *int     `json:"minLength,omitempty"`
+	MaxLength   *int     `json:"maxLength,omitempty"`
+	Minimum     *float64 `json:"minimum,omitempty"`
+	Maximum     *float64 `json:"maximum,omitempty"`
+	MinItems    *int     `json:"minItems,omitempty"`
+	MaxItems    *int     `json:"maxItems,omitempty"`
+	Items       *Schema  `json:"items,omitempty"`
+	// Properties and Required describe objects, AdditionalProperties is either false or the schema of map values.
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// queryParameter describes an optional query parameter.
+func queryParameter(name string, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func integerSchema(minimum float64, maximum float64) *Schema {
+	return &Schema{Type: "integer", Minimum: &minimum, Maximum: &maximum}
+}
+
+func numberSchema(minimum float64, maximum float64) *Schema {
+	return &Schema{Type: "number", Minimum: &minimum, Maximum: &maximum}
+}
+
+func stringSchema(minLength int, maxLength int) *Schema {
+	return &Schema{Type: "string", MinLength: &minLength, MaxLength: &maxLength}
+}
+
+func arraySchema(items *Schema, minItems int, maxItems int) *Schema {
+	return &Schema{Type: "array", Items: items, MinItems: &minItems, MaxItems: &maxItems}
+}
+
+func enumSchema(values []string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+func sortedKeys(values interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(values).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// locationParameters are the query parameters parsed by parseLocation.
+func locationParameters() []Parameter {
+	return []Parameter{
+		queryParameter("city", `The city, optionally with its country like "Sydney, AU". Required unless lat and lon are given.`,
+			&Schema{Type: "string"}),
+		queryParameter("country", "The ISO 3166-1 alpha-2 code of the country of the city.",
+			&Schema{Type: "string", Pattern: "^[A-Za-z]{2}$"}),
+		queryParameter("lat", "The latitude, required along with lon unless city is given.", numberSchema(-90, 90)),
+		queryParameter("lon", "The longitude, required along with lat unless city is given.", numberSchema(-180, 180)),
+	}
+}
+
+// renderingParameters are the query parameters parsed by parseRendering and negotiateEncoder.
+func renderingParameters() []Parameter {
+	return []Parameter{
+		queryParameter("units", "The unit system of measurements.", enumSchema(sortedKeys(weather.UnitSystems))),
+		queryParameter("wind", "The wind speed unit, overriding the unit system.", enumSchema(sortedKeys(weather.SpeedUnitCodes))),
+		queryParameter("temp", "The temperature unit, overriding the unit system.",
+			enumSchema(sortedKeys(weather.TemperatureUnitCodes))),
+		queryParameter("precision", "The number of decimal places of measurements.", integerSchema(0, weather.MaxPrecision)),
+		queryParameter("format", "The representation of the response, overriding the Accept header.",
+			enumSchema(supportedFormats())),
+	}
+}
+
+// envelopeParameter is the query parameter parsed by parseEnvelope,
+// routes taking it may respond with their data wrapped into an envelope.
+func envelopeParameter() Parameter {
+	return queryParameter("envelope", "Whether to wrap the response along with its metadata.", &Schema{Type: "boolean"})
+}
+
+// enumerations are the values of string types of the unified model.
+var enumerations = map[reflect.Type][]string{
+	reflect.TypeOf(weather.SpeedUnit("")):       enumValues(weather.SpeedUnitCodes),
+	reflect.TypeOf(weather.TemperatureUnit("")): enumValues(weather.TemperatureUnitCodes),
+}
+
+func enumValues(codes interface{}) []string {
+	var values []string
+	codesValue := reflect.ValueOf(codes)
+	for _, key := range codesValue.MapKeys() {
+		values = append(values, codesValue.MapIndex(key).String())
+	}
+	sort.Strings(values)
+	return values
+}
+
+type specification struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       specificationInfo               `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components specificationComponents         `json:"components"`
+}
+
+type specificationInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type specificationComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// buildSpecification describes the routers in an OpenAPI 3 document,
+// along with the schemas of the types of their responses.
+func buildSpecification(routers []Router) specification {
+	generator := schemaGenerator{schemas: make(map[string]*Schema)}
+	problemSchema := generator.schemaOf(reflect.TypeOf(problem{}))
+	spec := specification{
+		OpenAPI:    "3.0.3",
+		Info:       specificationInfo{Title: "Weather Reporter", Version: "1.0.0"},
+		Paths:      make(map[string]map[string]operation),
+		Components: specificationComponents{Schemas: generator.schemas},
+	}
+	for _, router := range routers {
+		op := operation{
+			Summary:    router.Summary,
+			Parameters: router.Parameters,
+			Responses: map[string]response{
+				"default": {
+					Description: "An error",
+					Content:     map[string]mediaType{problemContentType: {Schema: problemSchema}},
+				},
+			},
+		}
+		if router.Body != nil {
+			op.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{"application/json": {Schema: router.Body}}}
+		}
+		op.Responses["200"] = response{
+			Description: "The data, in the representation negotiated by the Accept header or the format query parameter",
+			Content:     map[string]mediaType{"application/json": {Schema: generator.responseSchema(router)}},
+		}
+		if router.Method == http.MethodGet {
+			op.Responses["304"] = response{Description: "The data has not changed since the client got it"}
+		}
+		if spec.Paths[router.Path] == nil {
+			spec.Paths[router.Path] = make(map[string]operation)
+		}
+		spec.Paths[router.Path][strings.ToLower(router.Method)] = op
+	}
+	return spec
+}
+
+// responseSchema describes the response of the router, which may also be wrapped into an envelope.
+func (g *schemaGenerator) responseSchema(router Router) *Schema {
+	schema := g.schemaOf(reflect.TypeOf(router.Response))
+	for _, parameter := range router.Parameters {
+		if parameter.Name == envelopeParameter().Name {
+			return &Schema{OneOf: []*Schema{schema, {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"data":     schema,
+					"metadata": g.schemaOf(reflect.TypeOf(responseMetadata{})),
+				},
+				Required:             []string{"data", "metadata"},
+				AdditionalProperties: false,
+			}}}
+		}
+	}
+	return schema
+}
+
+// schemaGenerator derives schemas from the types of responses as they are marshalled to json.
+// Named structs are described once among the components and referenced.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if values, ok := enumerations[t]; ok {
+		return enumSchema(values)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := g.schemas[name]; !ok {
+			// registered before its fields are described, so that recursive types reference it
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: componentsPrefix + name}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema describes the exported fields of a struct by their json names. Fields without omitempty are required,
+// and may be null when nil.
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitEmpty := false
+		for _, option := range tag[1:] {
+			omitEmpty = omitEmpty || option == "omitempty"
+		}
+		fieldSchema := g.schemaOf(f.Type)
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+			switch f.Type.Kind() {
+			case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+				if fieldSchema.Ref != "" {
+					// siblings of references are ignored, so the reference is wrapped to be nullable
+					fieldSchema = &Schema{OneOf: []*Schema{fieldSchema}}
+				}
+				fieldSchema.Nullable = true
+			}
+		}
+		schema.Properties[name] = fieldSchema
+	}
+	return schema
+}
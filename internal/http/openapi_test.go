@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"weather-reporter/internal/gazetteer"
+	"weather-reporter/internal/weather"
+)
+
+// testRouters are all routers with handlers responding like the service does.
+func testRouters() []Router {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	gust := 30.5
+	w := weather.Weather{
+		WindSpeed:          20,
+		TemperatureDegrees: 29,
+		Units:              weather.CanonicalUnits,
+		WindGust:           &gust,
+		Condition:          &weather.Condition{Code: "800", Description: "clear sky"},
+		ObservedAt:         &observedAt,
+		Provider:           "yahoo",
+	}
+	metadata := weather.Metadata{Provider: "yahoo", ObservedAt: &observedAt}
+	return []Router{
+		CreateWeatherHttpRouter(func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+			return w.Convert(request.Units), metadata, nil
+		}),
+		CreateBatchWeatherHttpRouter(func(ctx context.Context, request BatchWeatherRequest) (interface{}, error) {
+			return map[string]weather.BatchResult{"Sydney": {Weather: &w}, "Gotham": {Error: "unknown location"}}, nil
+		}, 10),
+		CreateForecastHttpRouter(func(ctx context.Context, request ForecastRequest) (interface{}, weather.Metadata, error) {
+			return weather.Forecast{
+				Days:  []weather.DailyForecast{{Date: "2019-01-02", TemperatureMin: 18, TemperatureMax: 29}},
+				Units: weather.CanonicalUnits,
+			}, metadata, nil
+		}),
+		CreateHourlyForecastHttpRouter(func(ctx context.Context, request HourlyForecastRequest) (interface{}, weather.Metadata, error) {
+			entry := weather.NewHourlyEntry(observedAt, time.FixedZone("", 11*3600))
+			return weather.HourlyForecast{Hours: []weather.HourlyEntry{entry}, TimeZone: "+11:00", Units: weather.CanonicalUnits},
+				metadata, nil
+		}),
+		CreateLocationsHttpRouter(func(ctx context.Context, request LocationsRequest) (interface{}, error) {
+			return []gazetteer.Place{{ID: "geonames:2147714", Name: "Sydney", Country: "AU", Population: 4627345}}, nil
+		}),
+	}
+}
+
+func serveRoot(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return recorder
+}
+
+func Test_Should_Serve_Specification_Of_Every_Route(t *testing.T) {
+	recorder := serveRoot(buildRootHandler(NoValidation, nil, testRouters()...), "GET", "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var spec map[string]interface{}
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+	paths := spec["paths"].(map[string]interface{})
+	for path, method := range map[string]string{
+		"/v1/weather":         "get",
+		"/v1/weather:batch":   "post",
+		"/v1/forecast":        "get",
+		"/v1/forecast/hourly": "get",
+		"/v1/locations":       "get",
+	} {
+		assert.Contains(t, paths[path], method, path)
+	}
+}
+
+func Test_Should_Describe_Query_Constraints(t *testing.T) {
+	spec := buildSpecification(testRouters())
+	parameters := make(map[string]Parameter)
+	for _, parameter := range spec.Paths["/v1/forecast"]["get"].Parameters {
+		parameters[parameter.Name] = parameter
+	}
+	assert.Equal(t, float64(1), *parameters["days"].Schema.Minimum)
+	assert.Equal(t, float64(weather.MaxForecastDays), *parameters["days"].Schema.Maximum)
+	assert.Equal(t, float64(-90), *parameters["lat"].Schema.Minimum)
+	assert.Equal(t, float64(weather.MaxPrecision), *parameters["precision"].Schema.Maximum)
+	assert.Equal(t, []string{"imperial", "metric", "si"}, parameters["units"].Schema.Enum)
+	assert.Equal(t, "boolean", parameters["envelope"].Schema.Type)
+
+	q := spec.Paths["/v1/locations"]["get"].Parameters[0]
+	assert.True(t, q.Required)
+	assert.Equal(t, maxLocationsQuery, *q.Schema.MaxLength)
+}
+
+func Test_Should_Describe_Weather_Schema(t *testing.T) {
+	spec := buildSpecification(testRouters())
+	schema := spec.Components.Schemas["Weather"]
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"wind_speed", "temperature_degrees", "units"}, schema.Required)
+	assert.Equal(t, "number", schema.Properties["wind_speed"].Type)
+	assert.Equal(t, componentsPrefix+"Units", schema.Properties["units"].Ref)
+	assert.Equal(t, "date-time", schema.Properties["observed_at"].Format)
+	assert.Equal(t, []string{"celsius", "fahrenheit", "kelvin"}, spec.Components.Schemas["Units"].Properties["temperature"].Enum)
+
+	response := spec.Paths["/v1/weather"]["get"].Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, componentsPrefix+"Weather", response.OneOf[0].Ref)
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"strconv"
+	"weather-reporter/internal/weather"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem is an error response body as defined by RFC 7807.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// errorStatuses maps kinds of weather errors to response statuses.
+var errorStatuses = map[weather.ErrorKind]int{
+	weather.Internal:             http.StatusInternalServerError,
+	weather.InvalidInput:         http.StatusBadRequest,
+	weather.UnknownLocation:      http.StatusNotFound,
+	weather.ProvidersFailed:      http.StatusBadGateway,
+	weather.ProvidersUnavailable: http.StatusServiceUnavailable,
+	weather.RateLimited:          http.StatusTooManyRequests,
+}
+
+// sendHandlerError responds to an error of a handler with the status matching its kind.
+// Only errors caused by the caller have their message in the response, others are logged.
+func sendHandlerError(writer http.ResponseWriter, err error) {
+	e := weather.AsError(err)
+	status := errorStatuses[e.Kind]
+	if status >= http.StatusInternalServerError {
+		log.WithField("error", fmt.Sprintf("%+v", err)).Error("failed to retrieve data")
+	} else {
+		log.WithField("error", err).Debug(http.StatusText(status))
+	}
+	if e.RetryAfter > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	writeErrorResponse(writer, status, weather.ErrorDetail(err))
+}
+
+func sendErrorResponse(writer http.ResponseWriter, err error) {
+	log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+	writeErrorResponse(writer, http.StatusInternalServerError, "internal error")
+}
+
+func sendBadRequestResponse(writer http.ResponseWriter, err error) {
+	log.WithField("error", err).Debug("bad request")
+	writeErrorResponse(writer, http.StatusBadRequest, err.Error())
+}
+
+func writeErrorResponse(writer http.ResponseWriter, statusCode int, detail string) {
+	body, err := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to marshal problem")
+		log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+		writer.WriteHeader(statusCode)
+		return
+	}
+	writer.Header().Set("Content-Type", problemContentType)
+	writer.WriteHeader(statusCode)
+	_, err = writer.Write(body)
+	if err != nil {
+		err = errors.Wrap(err, "failed to write response")
+		log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+	}
+}
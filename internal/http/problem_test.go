@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+func serveWeather(handler WeatherHandler, query string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/v1/weather?"+query, nil)
+	CreateWeatherHttpRouter(handler).Handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func failingHandler(err error) WeatherHandler {
+	return func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		return nil, weather.Metadata{}, err
+	}
+}
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) problem {
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	var body problem
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	return body
+}
+
+func Test_Should_Map_Error_Kinds_To_Statuses(t *testing.T) {
+	for kind, status := range map[weather.ErrorKind]int{
+		weather.Internal:             http.StatusInternalServerError,
+		weather.InvalidInput:         http.StatusBadRequest,
+		weather.UnknownLocation:      http.StatusNotFound,
+		weather.ProvidersFailed:      http.StatusBadGateway,
+		weather.ProvidersUnavailable: http.StatusServiceUnavailable,
+		weather.RateLimited:          http.StatusTooManyRequests,
+	} {
+		err := errors.Wrap(&weather.Error{Kind: kind, Err: errors.New("error-1")}, "wrapped")
+		recorder := serveWeather(failingHandler(err), "city=sydney")
+		assert.Equal(t, status, recorder.Code)
+		body := decodeProblem(t, recorder)
+		assert.Equal(t, status, body.Status)
+		assert.Equal(t, http.StatusText(status), body.Title)
+	}
+}
+
+func Test_Should_Respond_Not_Found_For_Unknown_Location(t *testing.T) {
+	recorder := serveWeather(failingHandler(errors.Wrap(weather.ErrUnknownLocation, "atlantis")), "city=atlantis")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "atlantis: unknown location", decodeProblem(t, recorder).Detail)
+}
+
+func Test_Should_Not_Leak_Provider_Errors(t *testing.T) {
+	err := &weather.Error{Kind: weather.ProvidersFailed, Err: errors.New("Get http://provider?appid=secret")}
+	recorder := serveWeather(failingHandler(err), "city=sydney")
+	body := decodeProblem(t, recorder)
+	assert.Equal(t, "all weather providers failed", body.Detail)
+	assert.NotContains(t, recorder.Body.String(), "secret")
+}
+
+func Test_Should_Set_Retry_After_In_Whole_Seconds(t *testing.T) {
+	err := &weather.Error{Kind: weather.RateLimited, RetryAfter: 1500 * time.Millisecond, Err: errors.New("error-1")}
+	recorder := serveWeather(failingHandler(err), "city=sydney")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+}
+
+func Test_Should_Not_Set_Retry_After_Without_Delay(t *testing.T) {
+	err := &weather.Error{Kind: weather.ProvidersUnavailable, Err: errors.New("error-1")}
+	recorder := serveWeather(failingHandler(err), "city=sydney")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Retry-After"))
+}
+
+func Test_Should_Respond_Bad_Request_For_Invalid_Query(t *testing.T) {
+	handler := func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		t.Fatal("handler must not be called")
+		return nil, weather.Metadata{}, nil
+	}
+	for _, query := range []string{"", "city=123", "city=sydney&country=AUS", "lat=91&lon=0", "city=sydney&units=nautical", "city=sydney&envelope=maybe"} {
+		recorder := serveWeather(handler, query)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		assert.NotEmpty(t, decodeProblem(t, recorder).Detail, query)
+	}
+}
+
+func Test_Should_Respond_With_Weather(t *testing.T) {
+	handler := func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		assert.Equal(t, weather.Location{City: "São Paulo", Country: "BR"}, request.Location)
+		return weather.Weather{TemperatureDegrees: 1}, weather.Metadata{}, nil
+	}
+	recorder := serveWeather(handler, "city=S%C3%A3o+Paulo&country=br")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+}
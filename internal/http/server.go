@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -20,24 +21,44 @@ type Router struct {
 	Path    string
 	Queries []string
 	Handler http.Handler
+	// Summary describes the route in the OpenAPI specification.
+	Summary string
+	// Parameters are the query parameters of the route.
+	Parameters []Parameter
+	// Body is the schema of the json request body, nil when the route takes no body.
+	Body *Schema
+	// Response is a value of the type of the data the route responds with.
+	Response interface{}
 }
 
+// HealthReporter returns details to be included in the /health response.
+type HealthReporter func() map[string]interface{}
+
 type HttpServer interface {
 	Start() error
 	Stop() error
 }
 
-func NewHttpServer(serverPort int, routers ...Router) HttpServer {
+// shutdownTimeout is the time in-flight requests are given to complete before they are cancelled
+const shutdownTimeout = time.Second * 10
+
+// NewHttpServer creates a server of the routers, which checks requests against their OpenAPI specification
+// up to the validation level.
+func NewHttpServer(serverPort int, validation Validation, healthReporter HealthReporter, routers ...Router) HttpServer {
+	baseContext, cancel := context.WithCancel(context.Background())
 	return &httpServer{
 		server: http.Server{
-			Addr:    ":" + strconv.Itoa(serverPort),
-			Handler: buildRootHandler(routers...),
+			Addr:        ":" + strconv.Itoa(serverPort),
+			Handler:     buildRootHandler(validation, healthReporter, routers...),
+			BaseContext: func(net.Listener) context.Context { return baseContext },
 		},
+		cancel: cancel,
 	}
 }
 
 type httpServer struct {
 	server http.Server
+	cancel context.CancelFunc
 }
 
 func (s *httpServer) Start() error {
@@ -50,24 +71,49 @@ func (s *httpServer) Start() error {
 }
 
 func (s *httpServer) Stop() error {
-	return errors.Wrap(s.server.Shutdown(context.Background()), "failed to stop server")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	// requests still running after the timeout are cancelled along with their upstream calls
+	defer s.cancel()
+	return errors.Wrap(s.server.Shutdown(ctx), "failed to stop server")
 }
 
-func buildRootHandler(routers ...Router) http.Handler {
+func buildRootHandler(validation Validation, healthReporter HealthReporter, routers ...Router) http.Handler {
+	spec := buildSpecification(routers)
 	rootRouter := mux.NewRouter()
-	rootRouter.HandleFunc("/health", handleHealthRequest).Methods("GET")
+	rootRouter.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		handleHealthRequest(w, r, healthReporter)
+	}).Methods("GET")
 	rootRouter.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{})).Methods("GET")
+	rootRouter.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		handleSpecificationRequest(w, spec)
+	}).Methods("GET")
 	for _, router := range routers {
 		rootRouter.NewRoute().Methods(router.Method).Path(router.Path).Queries(router.Queries...).
-			Handler(instrumentHandler(router.Handler))
+			Handler(instrumentHandler(validateHandler(spec, router, validation, router.Handler)))
 	}
 	rootRouter.Use(logRequestsHandler, handlers.RecoveryHandler(handlers.PrintRecoveryStack(true)))
 	return rootRouter
 }
 
-func handleHealthRequest(writer http.ResponseWriter, request *http.Request) {
+func handleHealthRequest(writer http.ResponseWriter, request *http.Request, healthReporter HealthReporter) {
+	health := map[string]interface{}{}
+	if healthReporter != nil {
+		for key, value := range healthReporter() {
+			health[key] = value
+		}
+	}
+	health["status"] = "SERVING"
+	writer.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(writer).Encode(health)
+	if err != nil {
+		log.Errorln(err)
+	}
+}
+
+func handleSpecificationRequest(writer http.ResponseWriter, spec specification) {
 	writer.Header().Set("Content-Type", "application/json")
-	_, err := fmt.Fprint(writer, `{"status": "SERVING"}`)
+	err := json.NewEncoder(writer).Encode(spec)
 	if err != nil {
 		log.Errorln(err)
 	}
@@ -0,0 +1,352 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Validation is the level of checks of requests and responses against the OpenAPI specification.
+type Validation int
+
+const (
+	// NoValidation passes requests to handlers as they are, leaving handlers to check their parameters.
+	NoValidation Validation = iota
+	// ValidateRequests answers requests not matching the specification with 400 Bad Request.
+	ValidateRequests
+	// ValidateResponses also replaces responses not matching the specification with 500 Internal Server Error,
+	// so that tests catch any drift of handlers from the specification.
+	ValidateResponses
+)
+
+// validator checks values decoded from json with numbers as json.Number against schemas of a specification.
+type validator struct {
+	schemas map[string]*Schema
+}
+
+// validateHandler checks requests to the router and, with ValidateResponses, its responses against the specification.
+func validateHandler(spec specification, router Router, validation Validation, handler http.Handler) http.Handler {
+	if validation == NoValidation {
+		return handler
+	}
+	v := validator{schemas: spec.Components.Schemas}
+	op := spec.Paths[router.Path][strings.ToLower(router.Method)]
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if err := v.validateRequest(request, op); err != nil {
+			sendBadRequestResponse(writer, err)
+			return
+		}
+		if validation != ValidateResponses {
+			handler.ServeHTTP(writer, request)
+			return
+		}
+		recorder := &responseRecorder{header: make(http.Header), statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, request)
+		if err := v.validateResponse(recorder, op); err != nil {
+			log.WithField("error", err).Error("response does not match the specification")
+			writeErrorResponse(writer, http.StatusInternalServerError,
+				fmt.Sprintf("response does not match the specification: %v", err))
+			return
+		}
+		recorder.writeTo(writer)
+	})
+}
+
+func (v validator) validateRequest(request *http.Request, op operation) error {
+	query := request.URL.Query()
+	for _, parameter := range op.Parameters {
+		value := query.Get(parameter.Name)
+		if value == "" {
+			if parameter.Required {
+				return errors.Errorf("query parameter %v is required", parameter.Name)
+			}
+			continue
+		}
+		path := "query parameter " + parameter.Name
+		parsed, err := parseParameter(value, parameter.Schema, path)
+		if err != nil {
+			return err
+		}
+		if err := v.validate(parsed, parameter.Schema, path); err != nil {
+			return err
+		}
+	}
+	if op.RequestBody == nil {
+		return nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(nil, request.Body, maxBatchBodySize))
+	if err != nil {
+		return errors.Wrap(err, "failed to read body")
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	value, err := decodeJson(body)
+	if err != nil {
+		return errors.New("body must be json")
+	}
+	return errors.Wrap(v.validate(value, op.RequestBody.Content["application/json"].Schema, "body"), "invalid body")
+}
+
+// parseParameter converts a query parameter to the type of its schema.
+func parseParameter(value string, schema *Schema, path string) (interface{}, error) {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errors.Errorf("%v must be an integer, got %q", path, value)
+		}
+		return json.Number(value), nil
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, errors.Errorf("%v must be a number, got %q", path, value)
+		}
+		return json.Number(value), nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Errorf("%v must be true or false, got %q", path, value)
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+// validateResponse checks json responses against the schema of their status, or of the default response.
+func (v validator) validateResponse(recorder *responseRecorder, op operation) error {
+	if recorder.body.Len() == 0 {
+		return nil
+	}
+	contentType, _, _ := mime.ParseMediaType(recorder.header.Get("Content-Type"))
+	r, ok := op.Responses[strconv.Itoa(recorder.statusCode)]
+	if !ok {
+		r = op.Responses["default"]
+	}
+	content, ok := r.Content[contentType]
+	if !ok {
+		if contentType == "application/json" || contentType == problemContentType {
+			return errors.Errorf("unexpected %v response with status %v", contentType, recorder.statusCode)
+		}
+		// other representations are derived from json, which is checked
+		return nil
+	}
+	value, err := decodeJson(recorder.body.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "response is not json")
+	}
+	return v.validate(value, content.Schema, "response")
+}
+
+func decodeJson(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.Wrap(err, "failed to decode json")
+	}
+	return value, nil
+}
+
+func (v validator) validate(value interface{}, schema *Schema, path string) error {
+	if schema.Ref != "" {
+		referenced, ok := v.schemas[strings.TrimPrefix(schema.Ref, componentsPrefix)]
+		if !ok {
+			return errors.Errorf("%v has unknown schema %v", path, schema.Ref)
+		}
+		return v.validate(value, referenced, path)
+	}
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.OneOf) == 0) {
+			return nil
+		}
+		return errors.Errorf("%v must not be null", path)
+	}
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		var lastError error
+		for _, s := range schema.OneOf {
+			if err := v.validate(value, s, path); err != nil {
+				lastError = err
+			} else {
+				matches++
+			}
+		}
+		if matches == 0 {
+			return lastError
+		}
+		if matches > 1 {
+			return errors.Errorf("%v matches more than one schema", path)
+		}
+	}
+	switch schema.Type {
+	case "object":
+		return v.validateObject(value, schema, path)
+	case "array":
+		return v.validateArray(value, schema, path)
+	case "string":
+		return validateString(value, schema, path)
+	case "integer", "number":
+		return validateNumber(value, schema, path)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return errors.Errorf("%v must be a boolean", path)
+		}
+	}
+	return nil
+}
+
+func (v validator) validateObject(value interface{}, schema *Schema, path string) error {
+	o, ok := value.(map[string]interface{})
+	if !ok {
+		return errors.Errorf("%v must be an object", path)
+	}
+	for _, name := range schema.Required {
+		if _, ok := o[name]; !ok {
+			return errors.Errorf("%v.%v is required", path, name)
+		}
+	}
+	for name, property := range o {
+		propertySchema, ok := schema.Properties[name]
+		if !ok {
+			switch additional := schema.AdditionalProperties.(type) {
+			case *Schema:
+				propertySchema = additional
+			case bool:
+				if !additional {
+					return errors.Errorf("%v.%v is not allowed", path, name)
+				}
+				continue
+			default:
+				continue
+			}
+		}
+		if err := v.validate(property, propertySchema, path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v validator) validateArray(value interface{}, schema *Schema, path string) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return errors.Errorf("%v must be an array", path)
+	}
+	if schema.MinItems != nil && len(items) < *schema.MinItems {
+		return errors.Errorf("%v must have at least %v items", path, *schema.MinItems)
+	}
+	if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+		return errors.Errorf("%v must have at most %v items", path, *schema.MaxItems)
+	}
+	if schema.Items == nil {
+		return nil
+	}
+	for i, item := range items {
+		if err := v.validate(item, schema.Items, fmt.Sprintf("%v[%v]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(value interface{}, schema *Schema, path string) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.Errorf("%v must be a string", path)
+	}
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return errors.Errorf("%v must have at least %v characters", path, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return errors.Errorf("%v must have at most %v characters", path, *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		matched, err := regexp.MatchString(schema.Pattern, s)
+		if err != nil {
+			return errors.Wrapf(err, "%v has invalid pattern", path)
+		}
+		if !matched {
+			return errors.Errorf("%v must match %v", path, schema.Pattern)
+		}
+	}
+	if len(schema.Enum) > 0 && !containsString(schema.Enum, s) {
+		return errors.Errorf("%v must be one of %v", path, strings.Join(schema.Enum, ", "))
+	}
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return errors.Errorf("%v must be a date-time", path)
+		}
+	}
+	return nil
+}
+
+func validateNumber(value interface{}, schema *Schema, path string) error {
+	n, ok := value.(json.Number)
+	if !ok {
+		return errors.Errorf("%v must be a number", path)
+	}
+	if schema.Type == "integer" {
+		if _, err := n.Int64(); err != nil {
+			return errors.Errorf("%v must be an integer", path)
+		}
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return errors.Errorf("%v must be a number", path)
+	}
+	if schema.Minimum != nil && f < *schema.Minimum {
+		return errors.Errorf("%v must be at least %v", path, *schema.Minimum)
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		return errors.Errorf("%v must be at most %v", path, *schema.Maximum)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder keeps a response to be checked before it is written.
+type responseRecorder struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *responseRecorder) writeTo(writer http.ResponseWriter) {
+	for key, values := range r.header {
+		writer.Header()[key] = values
+	}
+	writer.WriteHeader(r.statusCode)
+	if _, err := writer.Write(r.body.Bytes()); err != nil {
+		err = errors.Wrap(err, "failed to write response")
+		log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+	}
+}
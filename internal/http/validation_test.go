@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"weather-reporter/internal/weather"
+)
+
+func Test_Should_Respond_According_To_Specification(t *testing.T) {
+	handler := buildRootHandler(ValidateResponses, nil, testRouters()...)
+	for _, target := range []string{
+		"/v1/weather?city=sydney",
+		"/v1/weather?city=sydney&envelope=true&units=imperial",
+		"/v1/weather?lat=-33.87&lon=151.21&precision=2",
+		"/v1/forecast?city=sydney&days=1",
+		"/v1/forecast?city=sydney&envelope=true",
+		"/v1/forecast/hourly?city=sydney&hours=1",
+		"/v1/locations?q=syd&limit=5",
+		"/v1/weather?city=sydney&format=xml",
+	} {
+		recorder := serveRoot(handler, "GET", target, "")
+		assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	recorder := serveRoot(handler, "POST", "/v1/weather:batch", `{"cities": ["sydney", "gotham"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
+
+func Test_Should_Reject_Requests_Not_Matching_Specification(t *testing.T) {
+	handler := buildRootHandler(ValidateRequests, nil, testRouters()...)
+	for target, detail := range map[string]string{
+		"/v1/weather?city=sydney&precision=7":    "query parameter precision must be at most 6",
+		"/v1/weather?city=sydney&precision=one":  `query parameter precision must be an integer, got "one"`,
+		"/v1/weather?city=sydney&units=nautical": "query parameter units must be one of imperial, metric, si",
+		"/v1/weather?city=sydney&country=AUS":    "query parameter country must match ^[A-Za-z]{2}$",
+		"/v1/forecast?city=sydney&days=6":        "query parameter days must be at most 5",
+		"/v1/locations?limit=5":                  "query parameter q is required",
+	} {
+		recorder := serveRoot(handler, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, target)
+		assert.Equal(t, detail, decodeProblem(t, recorder).Detail, target)
+	}
+	for body, detail := range map[string]string{
+		`{"cities": []}`:  "invalid body: body.cities must have at least 1 items",
+		`{"cities": [1]}`: "invalid body: body.cities[0] must be a string",
+		`{}`:              "invalid body: body.cities is required",
+		`cities`:          "body must be json",
+	} {
+		recorder := serveRoot(handler, "POST", "/v1/weather:batch", body)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+		assert.Equal(t, detail, decodeProblem(t, recorder).Detail, body)
+	}
+}
+
+func Test_Should_Pass_Body_To_Handler_After_Validation(t *testing.T) {
+	var locations []weather.Location
+	router := CreateBatchWeatherHttpRouter(func(ctx context.Context, request BatchWeatherRequest) (interface{}, error) {
+		locations = request.Locations
+		return map[string]weather.BatchResult{}, nil
+	}, 10)
+	recorder := serveRoot(buildRootHandler(ValidateRequests, nil, router), "POST", "/v1/weather:batch", `{"cities": ["sydney"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []weather.Location{{City: "sydney"}}, locations)
+}
+
+func Test_Should_Not_Validate_Requests_By_Default(t *testing.T) {
+	recorder := serveRoot(buildRootHandler(NoValidation, nil, testRouters()...), "GET", "/v1/weather?city=sydney&precision=7", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "precision must be an integer from 0 to 6", decodeProblem(t, recorder).Detail)
+}
+
+func Test_Should_Catch_Responses_Drifting_From_Specification(t *testing.T) {
+	router := CreateWeatherHttpRouter(func(ctx context.Context, request WeatherRequest) (interface{}, weather.Metadata, error) {
+		return map[string]interface{}{"wind_speed": "calm", "temperature_degrees": 29}, weather.Metadata{}, nil
+	})
+	recorder := serveRoot(buildRootHandler(ValidateResponses, nil, router), "GET", "/v1/weather?city=sydney", "")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, decodeProblem(t, recorder).Detail, "response does not match the specification")
+}
+
+func Test_Should_Check_Error_Responses_Against_Problem_Schema(t *testing.T) {
+	router := CreateWeatherHttpRouter(failingHandler(&weather.Error{Kind: weather.UnknownLocation, Err: weather.ErrUnknownLocation}))
+	recorder := serveRoot(buildRootHandler(ValidateResponses, nil, router), "GET", "/v1/weather?city=gotham", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "unknown location", decodeProblem(t, recorder).Detail)
+}
@@ -1,57 +1,140 @@
 package http
 
 import (
-	"encoding/json"
-	"fmt"
-	"github.com/gorilla/mux"
+	"context"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 	"net/http"
+	"net/url"
+	"strconv"
+	"weather-reporter/internal/weather"
 )
 
-type WeatherHandler func(string) (interface{}, error)
+type WeatherRequest struct {
+	Location weather.Location
+	Units    weather.Units
+	// Precision is the number of decimal places of measurements.
+	Precision int
+}
+
+type WeatherHandler func(context.Context, WeatherRequest) (interface{}, weather.Metadata, error)
 
 func CreateWeatherHttpRouter(handler WeatherHandler) Router {
 	return Router{
-		Method:  "GET",
-		Path:    "/v1/weather",
-		Queries: []string{"city", "{city:[A-z]+}"},
+		Method: "GET",
+		Path:   "/v1/weather",
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			handleRequest(w, r, handler)
 		}),
+		Summary:    "Current weather of a city or coordinates",
+		Parameters: append(append(locationParameters(), renderingParameters()...), envelopeParameter()),
+		Response:   weather.Weather{},
 	}
 }
 
 func handleRequest(writer http.ResponseWriter, request *http.Request, handler WeatherHandler) {
-	routeVars := mux.Vars(request)
-	city := routeVars["city"]
-	data, err := handler(city)
+	handleResourceRequest(writer, request, func(ctx context.Context, location weather.Location, units weather.Units,
+		precision int) (interface{}, weather.Metadata, error) {
+		return handler(ctx, WeatherRequest{Location: location, Units: units, Precision: precision})
+	})
+}
+
+// resourceCall calls the handler of a weather resource with the parameters shared by all weather resources.
+type resourceCall func(ctx context.Context, location weather.Location, units weather.Units,
+	precision int) (interface{}, weather.Metadata, error)
+
+// handleResourceRequest is the request pipeline shared by all weather resources: it parses the shared query
+// parameters, negotiates the representation, calls the resource and sends its data with the metadata.
+func handleResourceRequest(writer http.ResponseWriter, request *http.Request, call resourceCall) {
+	query := request.URL.Query()
+	location, err := parseLocation(query)
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	units, precision, err := parseRendering(query)
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	enveloped, err := parseEnvelope(query)
+	if err != nil {
+		sendBadRequestResponse(writer, err)
+		return
+	}
+	e, ok := negotiate(writer, request)
+	if !ok {
+		return
+	}
+	data, metadata, err := call(request.Context(), location, units, precision)
 	if err != nil {
-		sendErrorResponse(writer, errors.Wrap(err, "failed to retrieve data"))
+		sendHandlerError(writer, err)
 		return
 	}
-	sendAsJson(writer, data)
+	sendWithMetadata(writer, request, e, data, metadata, enveloped)
 }
 
-func sendAsJson(writer http.ResponseWriter, data interface{}) {
-	response, err := json.Marshal(data)
+// parseLocation parses the location query parameters shared by all weather resources, either the city with
+// the optional country, which may also be given like "city=Sydney, AU", or the lat and lon.
+func parseLocation(query url.Values) (weather.Location, error) {
+	city := query.Get("city")
+	latitude := query.Get("lat")
+	longitude := query.Get("lon")
+	if city != "" && (latitude != "" || longitude != "") {
+		return weather.Location{}, errors.New("either city or lat and lon must be given, not both")
+	}
+	if city != "" && query.Get("country") == "" {
+		return weather.ParseCityLocation(city)
+	}
+	if city != "" {
+		return weather.NewCityLocation(city, query.Get("country"))
+	}
+	if latitude == "" || longitude == "" {
+		return weather.Location{}, errors.New("either city or lat and lon must be given")
+	}
+	lat, err := strconv.ParseFloat(latitude, 64)
 	if err != nil {
-		sendErrorResponse(writer, errors.Wrap(err, "failed to marshal json"))
-		return
+		return weather.Location{}, errors.Errorf("lat must be a number, got %q", latitude)
+	}
+	lon, err := strconv.ParseFloat(longitude, 64)
+	if err != nil {
+		return weather.Location{}, errors.Errorf("lon must be a number, got %q", longitude)
+	}
+	return weather.NewCoordinatesLocation(lat, lon)
+}
+
+// parseRendering parses units and precision query parameters shared by all weather resources.
+func parseRendering(query url.Values) (weather.Units, int, error) {
+	units, err := weather.ParseUnits(query.Get("units"), query.Get("wind"), query.Get("temp"))
+	if err != nil {
+		return weather.Units{}, 0, err
 	}
-	_, err = writer.Write(response)
+	precision, err := parsePrecision(query.Get("precision"))
 	if err != nil {
-		err = errors.Wrap(err, "failed to write response")
-		log.WithField("error", fmt.Sprintf("%+v", err)).Error()
+		return weather.Units{}, 0, err
+	}
+	return units, precision, nil
+}
+
+func parsePrecision(value string) (int, error) {
+	if value == "" {
+		return 0, nil
 	}
+	precision, err := strconv.Atoi(value)
+	if err != nil || precision < 0 || precision > weather.MaxPrecision {
+		return 0, errors.Errorf("precision must be an integer from 0 to %v", weather.MaxPrecision)
+	}
+	return precision, nil
 }
 
-func sendErrorResponse(writer http.ResponseWriter, err error) {
-	log.WithField("error", fmt.Sprintf("%+v", err)).Error()
-	writer.WriteHeader(http.StatusInternalServerError)
-	_, writeError := writer.Write([]byte(err.Error()))
-	if writeError != nil {
-		writeError = errors.Wrap(writeError, "failed to write response")
-		log.WithField("error", fmt.Sprintf("%+v", writeError)).Error()
+// parseCount parses the optional count query parameter name, e.g. the number of forecast days, from 1 to max.
+func parseCount(query url.Values, name string, defaultCount, max int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return defaultCount, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 || count > max {
+		return 0, errors.Errorf("%v must be an integer from 1 to %v", name, max)
 	}
+	return count, nil
 }
@@ -0,0 +1,70 @@
+package metar
+
+import "strings"
+
+var descriptors = map[string]string{
+	"MI": "shallow",
+	"PR": "partial",
+	"BC": "patches of",
+	"DR": "low drifting",
+	"BL": "blowing",
+	"FZ": "freezing",
+}
+
+var phenomena = map[string]string{
+	"DZ": "drizzle",
+	"RA": "rain",
+	"SN": "snow",
+	"SG": "snow grains",
+	"IC": "ice crystals",
+	"PL": "ice pellets",
+	"GR": "hail",
+	"GS": "small hail",
+	"UP": "unknown precipitation",
+	"BR": "mist",
+	"FG": "fog",
+	"FU": "smoke",
+	"VA": "volcanic ash",
+	"DU": "dust",
+	"SA": "sand",
+	"HZ": "haze",
+	"PY": "spray",
+	"PO": "dust whirls",
+	"SQ": "squalls",
+	"FC": "funnel cloud",
+	"SS": "sandstorm",
+	"DS": "duststorm",
+}
+
+// Describe describes a present weather group in words, e.g. "light rain showers" for "-SHRA",
+// or returns the group as it is when it is not a weather group.
+func Describe(group string) string {
+	match := weatherPattern.FindStringSubmatch(group)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return group
+	}
+	var names []string
+	for i := 0; i+2 <= len(match[3]); i += 2 {
+		names = append(names, phenomena[match[3][i:i+2]])
+	}
+	description := strings.Join(names, " and ")
+	switch match[2] {
+	case "":
+	case "SH":
+		description = strings.TrimSpace(description + " showers")
+	case "TS":
+		description = strings.TrimSpace("thunderstorm with " + description)
+		description = strings.TrimSuffix(description, " with")
+	default:
+		description = strings.TrimSpace(descriptors[match[2]] + " " + description)
+	}
+	switch match[1] {
+	case "-":
+		description = "light " + description
+	case "+":
+		description = "heavy " + description
+	case "VC":
+		description += " in the vicinity"
+	}
+	return description
+}
@@ -0,0 +1,27 @@
+package metar
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Should_Describe_Weather_Groups(t *testing.T) {
+	for group, description := range map[string]string{
+		"RA":      "rain",
+		"-SHRA":   "light rain showers",
+		"+TSRA":   "heavy thunderstorm with rain",
+		"TS":      "thunderstorm",
+		"VCSH":    "showers in the vicinity",
+		"FZFG":    "freezing fog",
+		"-RASN":   "light rain and snow",
+		"BCFG":    "patches of fog",
+		"+SHSNGS": "heavy snow and small hail showers",
+	} {
+		assert.Equal(t, description, Describe(group), group)
+	}
+}
+
+func Test_Should_Return_Unknown_Weather_Groups_As_They_Are(t *testing.T) {
+	assert.Equal(t, "XX", Describe("XX"))
+	assert.Equal(t, "-", Describe("-"))
+}
@@ -0,0 +1,265 @@
+// Package metar decodes METAR and SPECI aviation routine weather reports, e.g.
+// "METAR YSSY 221000Z 13010G18KT 100V160 9999 -RA FEW020 SCT035 21/14 Q1015".
+//
+// Only the observation itself is decoded, trends and remarks are ignored, as are groups that are not understood.
+package metar
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Speed units of wind groups.
+const (
+	Knots             = "KT"
+	MetresPerSecond   = "MPS"
+	KilometresPerHour = "KMH"
+)
+
+// MaxVisibility is the visibility in metres reported as 9999 or CAVOK, meaning 10 km or more.
+const MaxVisibility = 10000.0
+
+const (
+	metresPerMile = 1609.344
+	hPaPerInHg    = 33.8639
+)
+
+// Report is a decoded METAR. Measurements missing from the report are nil.
+type Report struct {
+	// Station is the ICAO code of the airport, e.g. "YSSY".
+	Station string
+	// Day, Hour and Minute are the day of the month and the time of the observation in UTC.
+	Day    int
+	Hour   int
+	Minute int
+	// Auto tells whether the report was made without human intervention.
+	Auto bool
+	Wind *Wind
+	// Visibility is the prevailing visibility in metres.
+	Visibility *float64
+	// Weather are the present weather groups, e.g. "-RA" or "VCTS".
+	Weather []string
+	Clouds  []Cloud
+	// Temperature and DewPoint are in degrees Celsius.
+	Temperature *float64
+	DewPoint    *float64
+	// Pressure is the altimeter setting (QNH) in hPa.
+	Pressure *float64
+}
+
+// Wind is the mean wind of the last 10 minutes.
+type Wind struct {
+	// Direction is in degrees the wind blows from, nil when it is variable or calm.
+	Direction *float64
+	// Speed and Gust are in the Unit, one of Knots, MetresPerSecond or KilometresPerHour.
+	Speed float64
+	Gust  *float64
+	Unit  string
+	// VariableFrom and VariableTo bound the direction when it varies, clockwise.
+	VariableFrom *float64
+	VariableTo   *float64
+}
+
+// Cloud is a layer of clouds.
+type Cloud struct {
+	// Cover is one of FEW, SCT, BKN, OVC, or VV when the sky is obscured.
+	Cover string
+	// Height is the height of the base of the layer in feet, nil when it is not reported.
+	Height *int
+	// Type is CB for cumulonimbus, TCU for towering cumulus, or empty.
+	Type string
+}
+
+var (
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	timePattern        = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	windPattern        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	variablePattern    = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	visibilityPattern  = regexp.MustCompile(`^(\d{4})(?:NDV)?$`)
+	milesPattern       = regexp.MustCompile(`^([MP])?(\d{1,2})?(?:(\d)/(\d{1,2}))?SM$`)
+	wholeMilesPattern  = regexp.MustCompile(`^\d$`)
+	weatherPattern     = regexp.MustCompile(`^(-|\+|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+	cloudPattern       = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	qnhPattern         = regexp.MustCompile(`^Q(\d{4})$`)
+	altimeterPattern   = regexp.MustCompile(`^A(\d{4})$`)
+)
+
+// Parse decodes a METAR, optionally starting with METAR or SPECI and ending with "=".
+func Parse(text string) (Report, error) {
+	groups := strings.Fields(strings.TrimSuffix(strings.TrimSpace(text), "="))
+	if len(groups) > 0 && (groups[0] == "METAR" || groups[0] == "SPECI") {
+		groups = groups[1:]
+	}
+	if len(groups) > 0 && groups[0] == "COR" {
+		groups = groups[1:]
+	}
+	if len(groups) == 0 {
+		return Report{}, errors.New("metar: empty report")
+	}
+	if !stationPattern.MatchString(groups[0]) {
+		return Report{}, errors.Errorf("metar: invalid station %q", groups[0])
+	}
+	report := Report{Station: groups[0]}
+	if len(groups) < 2 {
+		return Report{}, errors.Errorf("metar: %v report has no time", report.Station)
+	}
+	if err := report.parseTime(groups[1]); err != nil {
+		return Report{}, err
+	}
+	groups = groups[2:]
+	for i := 0; i < len(groups); i++ {
+		group := groups[i]
+		switch {
+		case group == "NIL":
+			return Report{}, errors.Errorf("metar: %v report is missing", report.Station)
+		case group == "RMK" || group == "NOSIG" || group == "TEMPO" || group == "BECMG":
+			// remarks and trends follow the observation
+			return report, nil
+		case group == "AUTO":
+			report.Auto = true
+		case group == "CAVOK":
+			report.Visibility = float(MaxVisibility)
+		case windPattern.MatchString(group):
+			report.Wind = parseWind(windPattern.FindStringSubmatch(group))
+		case variablePattern.MatchString(group) && report.Wind != nil:
+			match := variablePattern.FindStringSubmatch(group)
+			report.Wind.VariableFrom = parseFloat(match[1])
+			report.Wind.VariableTo = parseFloat(match[2])
+		case visibilityPattern.MatchString(group) && report.Visibility == nil:
+			report.Visibility = parseFloat(visibilityPattern.FindStringSubmatch(group)[1])
+			if *report.Visibility == 9999 {
+				report.Visibility = float(MaxVisibility)
+			}
+		case wholeMilesPattern.MatchString(group) && i+1 < len(groups) && milesPattern.MatchString(groups[i+1]):
+			// visibility of a whole and a fraction of miles, e.g. "1 1/2SM"
+			if miles, ok := parseMiles(milesPattern.FindStringSubmatch(groups[i+1])); ok {
+				report.Visibility = float((*parseFloat(group) + miles) * metresPerMile)
+			}
+			i++
+		case milesPattern.MatchString(group):
+			if miles, ok := parseMiles(milesPattern.FindStringSubmatch(group)); ok {
+				report.Visibility = float(miles * metresPerMile)
+			}
+		case group == "SKC" || group == "CLR" || group == "NSC" || group == "NCD":
+			report.Clouds = []Cloud{}
+		case cloudPattern.MatchString(group):
+			report.Clouds = append(report.Clouds, parseCloud(cloudPattern.FindStringSubmatch(group)))
+		case temperaturePattern.MatchString(group):
+			match := temperaturePattern.FindStringSubmatch(group)
+			report.Temperature = parseTemperature(match[1])
+			report.DewPoint = parseTemperature(match[2])
+		case qnhPattern.MatchString(group):
+			report.Pressure = parseFloat(qnhPattern.FindStringSubmatch(group)[1])
+		case altimeterPattern.MatchString(group):
+			inHg := *parseFloat(altimeterPattern.FindStringSubmatch(group)[1]) / 100
+			report.Pressure = float(inHg * hPaPerInHg)
+		case isWeather(group):
+			report.Weather = append(report.Weather, group)
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) parseTime(group string) error {
+	match := timePattern.FindStringSubmatch(group)
+	if match == nil {
+		return errors.Errorf("metar: invalid time %q of %v report", group, r.Station)
+	}
+	r.Day, _ = strconv.Atoi(match[1])
+	r.Hour, _ = strconv.Atoi(match[2])
+	r.Minute, _ = strconv.Atoi(match[3])
+	if r.Day < 1 || r.Day > 31 || r.Hour > 23 || r.Minute > 59 {
+		return errors.Errorf("metar: invalid time %q of %v report", group, r.Station)
+	}
+	return nil
+}
+
+// ObservedAt returns the time of the observation, which is the latest time of its day and time of day up to now.
+// Reports may be stamped slightly ahead of the clock, so times up to an hour ahead of now are kept in the month.
+func (r Report) ObservedAt(now time.Time) time.Time {
+	now = now.UTC()
+	for months := 0; months < 12; months++ {
+		month := time.Date(now.Year(), now.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		at := time.Date(month.Year(), month.Month(), r.Day, r.Hour, r.Minute, 0, 0, time.UTC)
+		// days past the end of the month roll over to the next one, such a month has no such day
+		if at.Month() == month.Month() && !at.After(now.Add(time.Hour)) {
+			return at
+		}
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), r.Hour, r.Minute, 0, 0, time.UTC)
+}
+
+func parseWind(match []string) *Wind {
+	wind := &Wind{Speed: *parseFloat(match[2]), Unit: match[4]}
+	if match[1] != "VRB" && (match[1] != "000" || wind.Speed != 0) {
+		wind.Direction = parseFloat(match[1])
+	}
+	if match[3] != "" {
+		wind.Gust = parseFloat(match[3])
+	}
+	return wind
+}
+
+// parseMiles returns the statute miles of a group like "10SM", "1/2SM" or "M1/4SM",
+// where M means less than and P more than the value.
+func parseMiles(match []string) (float64, bool) {
+	if match[2] == "" && match[3] == "" {
+		return 0, false
+	}
+	miles := 0.0
+	if match[2] != "" {
+		miles = *parseFloat(match[2])
+	}
+	if match[3] != "" {
+		denominator := *parseFloat(match[4])
+		if denominator == 0 {
+			return 0, false
+		}
+		miles += *parseFloat(match[3]) / denominator
+	}
+	return miles, true
+}
+
+func parseCloud(match []string) Cloud {
+	cloud := Cloud{Cover: match[1]}
+	if height, err := strconv.Atoi(match[2]); err == nil {
+		// heights are in hundreds of feet
+		height *= 100
+		cloud.Height = &height
+	}
+	if match[3] != "///" {
+		cloud.Type = match[3]
+	}
+	return cloud
+}
+
+// parseTemperature parses degrees like "21", or "M02" for negative ones.
+func parseTemperature(value string) *float64 {
+	if value == "" {
+		return nil
+	}
+	degrees := parseFloat(strings.TrimPrefix(value, "M"))
+	if strings.HasPrefix(value, "M") {
+		*degrees = -*degrees
+	}
+	return degrees
+}
+
+func isWeather(group string) bool {
+	match := weatherPattern.FindStringSubmatch(group)
+	return match != nil && (match[2] != "" || match[3] != "")
+}
+
+// parseFloat parses digits matched by a pattern, which always succeeds.
+func parseFloat(digits string) *float64 {
+	value, _ := strconv.ParseFloat(digits, 64)
+	return &value
+}
+
+func float(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,183 @@
+package metar
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_Should_Parse_Metar(t *testing.T) {
+	report, err := Parse("METAR YSSY 221000Z 13010G18KT 100V160 9999 -RA FEW020 SCT035CB 21/14 Q1015=")
+	assert.NoError(t, err)
+	height20, height35 := 2000, 3500
+	assert.Equal(t, Report{
+		Station: "YSSY",
+		Day:     22,
+		Hour:    10,
+		Minute:  0,
+		Wind: &Wind{
+			Direction:    float(130),
+			Speed:        10,
+			Gust:         float(18),
+			Unit:         Knots,
+			VariableFrom: float(100),
+			VariableTo:   float(160),
+		},
+		Visibility:  float(MaxVisibility),
+		Weather:     []string{"-RA"},
+		Clouds:      []Cloud{{Cover: "FEW", Height: &height20}, {Cover: "SCT", Height: &height35, Type: "CB"}},
+		Temperature: float(21),
+		DewPoint:    float(14),
+		Pressure:    float(1015),
+	}, report)
+}
+
+func Test_Should_Parse_Metar_Without_Prefix(t *testing.T) {
+	report, err := Parse("YSSY 221000Z AUTO 13010KT 9999 21/14 Q1015")
+	assert.NoError(t, err)
+	assert.Equal(t, "YSSY", report.Station)
+	assert.True(t, report.Auto)
+}
+
+func Test_Should_Parse_Variable_And_Calm_Wind(t *testing.T) {
+	report, err := Parse("METAR YSSY 221000Z VRB03KT CAVOK 21/14 Q1015")
+	assert.NoError(t, err)
+	assert.Equal(t, &Wind{Speed: 3, Unit: Knots}, report.Wind)
+	assert.Equal(t, float(MaxVisibility), report.Visibility)
+
+	report, err = Parse("METAR YSSY 221000Z 00000KT CAVOK 21/14 Q1015")
+	assert.NoError(t, err)
+	assert.Equal(t, &Wind{Speed: 0, Unit: Knots}, report.Wind)
+}
+
+func Test_Should_Parse_Wind_In_Other_Units(t *testing.T) {
+	report, err := Parse("METAR UUEE 221000Z 27005G12MPS 9999 M02/M05 Q1021")
+	assert.NoError(t, err)
+	assert.Equal(t, &Wind{Direction: float(270), Speed: 5, Gust: float(12), Unit: MetresPerSecond}, report.Wind)
+	assert.Equal(t, float(-2), report.Temperature)
+	assert.Equal(t, float(-5), report.DewPoint)
+}
+
+func Test_Should_Parse_Us_Metar(t *testing.T) {
+	report, err := Parse("METAR KSEA 221753Z 18012KT 1 1/2SM BR OVC008 12/11 A2992 RMK AO2 SLP133")
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.5*1609.344, *report.Visibility, 1e-9)
+	assert.InDelta(t, 1013.2, *report.Pressure, 0.1)
+	assert.Equal(t, []string{"BR"}, report.Weather)
+
+	report, err = Parse("METAR KSEA 221753Z 18012KT 10SM CLR 12/11 A2992")
+	assert.NoError(t, err)
+	assert.InDelta(t, 16093.44, *report.Visibility, 1e-9)
+	assert.Equal(t, []Cloud{}, report.Clouds)
+
+	report, err = Parse("METAR KSEA 221753Z 18012KT M1/4SM FG VV001 12/12 A2992")
+	assert.NoError(t, err)
+	assert.InDelta(t, 402.336, *report.Visibility, 1e-9)
+}
+
+func Test_Should_Leave_Out_Missing_Values(t *testing.T) {
+	report, err := Parse("METAR YSSY 221000Z /////KT //// // ////// 21/ Q////")
+	assert.NoError(t, err)
+	assert.Nil(t, report.Wind)
+	assert.Nil(t, report.Visibility)
+	assert.Nil(t, report.DewPoint)
+	assert.Nil(t, report.Pressure)
+	assert.Equal(t, float(21), report.Temperature)
+}
+
+func Test_Should_Ignore_Trends_And_Remarks(t *testing.T) {
+	report, err := Parse("METAR YSSY 221000Z 13010KT 9999 21/14 Q1015 TEMPO 3000 SHRA")
+	assert.NoError(t, err)
+	assert.Equal(t, float(MaxVisibility), report.Visibility)
+	assert.Empty(t, report.Weather)
+}
+
+func Test_Should_Return_Error_When_Metar_Is_Invalid(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"METAR",
+		"METAR yssy 221000Z",
+		"METAR YSSY",
+		"METAR YSSY 22100Z",
+		"METAR YSSY 321000Z",
+		"METAR YSSY 222400Z",
+		"METAR YSSY 221000Z NIL",
+	} {
+		_, err := Parse(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func Test_Should_Resolve_Observation_Time(t *testing.T) {
+	report := Report{Day: 22, Hour: 10, Minute: 30}
+	now := time.Date(2018, 10, 22, 11, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 10, 22, 10, 30, 0, 0, time.UTC), report.ObservedAt(now))
+
+	// stamped ahead of the clock
+	now = time.Date(2018, 10, 22, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 10, 22, 10, 30, 0, 0, time.UTC), report.ObservedAt(now))
+
+	// observed in the previous month
+	report = Report{Day: 31, Hour: 23, Minute: 30}
+	now = time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 10, 31, 23, 30, 0, 0, time.UTC), report.ObservedAt(now))
+
+	// skipping months without the day
+	now = time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 10, 31, 23, 30, 0, 0, time.UTC), report.ObservedAt(now))
+}
+
+var fuzzStationPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"METAR YSSY 221000Z 13010G18KT 100V160 9999 -RA FEW020 SCT035CB 21/14 Q1015=",
+		"SPECI COR YSSY 221000Z AUTO VRB03KT CAVOK M01/M03 Q0999",
+		"METAR KSEA 221753Z 18012KT 1 1/2SM +TSRA BR OVC008 12/11 A2992 RMK AO2",
+		"METAR KSEA 221753Z 18012KT M1/4SM FG VV001 12/12 A2992",
+		"YSSY 221000Z /////KT //// // ////// 21/ Q////",
+		"METAR YSSY 221000Z NIL",
+		"METAR YSSY 221000Z 13010KT 1 1/0SM 21/14",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		report, err := Parse(text)
+		if err != nil {
+			return
+		}
+		if !fuzzStationPattern.MatchString(report.Station) {
+			t.Errorf("invalid station %q", report.Station)
+		}
+		if report.Day < 1 || report.Day > 31 || report.Hour < 0 || report.Hour > 23 || report.Minute < 0 || report.Minute > 59 {
+			t.Errorf("invalid time %v %v:%v", report.Day, report.Hour, report.Minute)
+		}
+		if report.Wind != nil {
+			assertFinite(t, "wind speed", &report.Wind.Speed)
+			assertFinite(t, "wind gust", report.Wind.Gust)
+			if report.Wind.Direction != nil && (*report.Wind.Direction < 0 || *report.Wind.Direction > 999) {
+				t.Errorf("invalid wind direction %v", *report.Wind.Direction)
+			}
+		}
+		for name, value := range map[string]*float64{
+			"visibility": report.Visibility, "temperature": report.Temperature,
+			"dew point": report.DewPoint, "pressure": report.Pressure,
+		} {
+			assertFinite(t, name, value)
+		}
+		for _, group := range report.Weather {
+			if Describe(group) == "" {
+				t.Errorf("weather group %q has no description", group)
+			}
+		}
+		report.ObservedAt(time.Now())
+	})
+}
+
+func assertFinite(t *testing.T, name string, value *float64) {
+	if value != nil && (math.IsNaN(*value) || math.IsInf(*value, 0) || *value < -999 || *value > 1e6) {
+		t.Errorf("invalid %v %v", name, *value)
+	}
+}
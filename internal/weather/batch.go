@@ -0,0 +1,77 @@
+package weather
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// BatchResult is the result of a single location of a batch lookup, either the weather or the error detail.
+type BatchResult struct {
+	Weather *Weather `json:"weather,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type BatchService interface {
+	// GetCurrentWeatherBatch looks the weather of every location up, a failed location does not fail the others.
+	// Results are keyed by the location String.
+	GetCurrentWeatherBatch(ctx context.Context, locations []Location) map[string]BatchResult
+}
+
+// NewBatchService creates a batch service fanning lookups out to the service with at most concurrency
+// lookups running at once. Lookups share the cache and coalescing of the service.
+func NewBatchService(service Service, concurrency int) BatchService {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &batchService{
+		service:     service,
+		concurrency: concurrency,
+	}
+}
+
+type batchService struct {
+	service     Service
+	concurrency int
+}
+
+func (s *batchService) GetCurrentWeatherBatch(ctx context.Context, locations []Location) map[string]BatchResult {
+	results := make(map[string]BatchResult, len(locations))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.concurrency)
+	// seen makes duplicate locations looked up once
+	seen := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		key := location.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(key string, location Location) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			result := s.getCurrentWeather(ctx, location)
+			mutex.Lock()
+			results[key] = result
+			mutex.Unlock()
+		}(key, location)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *batchService) getCurrentWeather(ctx context.Context, location Location) BatchResult {
+	w, _, err := s.service.GetCurrentWeather(ctx, location)
+	if err != nil {
+		log.WithField("location", location.String()).
+			WithField("error", err).
+			Warn("failed to get weather of a batch location")
+		return BatchResult{Error: ErrorDetail(err)}
+	}
+	return BatchResult{Weather: &w}
+}
@@ -0,0 +1,60 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Should_Return_Weather_And_Errors_Per_City(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		if city == "unknown" {
+			return Weather{}, errors.New("city not found")
+		}
+		return Weather{TemperatureDegrees: 1}, nil
+	})
+	service := NewBatchService(NewWeatherService(NewWeatherCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p), 2)
+	results := service.GetCurrentWeatherBatch(context.Background(), []Location{{City: "sydney"}, {City: "unknown"}, {City: "melbourne"}})
+	assert.Len(t, results, 3)
+	assert.Equal(t, &Weather{TemperatureDegrees: 1}, results["sydney"].Weather)
+	assert.Equal(t, &Weather{TemperatureDegrees: 1}, results["melbourne"].Weather)
+	assert.Nil(t, results["unknown"].Weather)
+	assert.Equal(t, "all weather providers failed", results["unknown"].Error)
+}
+
+func Test_Should_Look_Duplicate_Batch_Cities_Up_Once(t *testing.T) {
+	calls := 0
+	p := provider(func(city string) (Weather, error) {
+		calls++
+		return Weather{}, nil
+	})
+	service := NewBatchService(NewWeatherService(NewWeatherCache(0, 0, 0), ServiceConfig{}, p), 1)
+	results := service.GetCurrentWeatherBatch(context.Background(), []Location{{City: "sydney"}, {City: "sydney"}})
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Should_Bound_Batch_Concurrency(t *testing.T) {
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	p := provider(func(city string) (Weather, error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return Weather{}, nil
+	})
+	service := NewBatchService(NewWeatherService(NewWeatherCache(0, 0, 0), ServiceConfig{}, p), 2)
+	results := service.GetCurrentWeatherBatch(context.Background(), []Location{{City: "a"}, {City: "b"}, {City: "c"}, {City: "d"}, {City: "e"}})
+	assert.Len(t, results, 5)
+	assert.Equal(t, 2, maxRunning)
+}
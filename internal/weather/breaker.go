@@ -0,0 +1,230 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is a state of a provider circuit breaker.
+type BreakerState int
+
+const (
+	// Closed breaker passes requests to the provider.
+	Closed BreakerState = iota
+	// HalfOpen breaker passes a single trial request to the provider.
+	HalfOpen
+	// Open breaker rejects requests without calling the provider.
+	Open
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+type BreakerConfig struct {
+	// FailureRatio of failed requests within a window that opens the breaker.
+	FailureRatio float64
+	// MinRequests within a window before the failure ratio is considered.
+	MinRequests int
+	// Window is the interval failures are counted in.
+	Window time.Duration
+	// CoolDown is the time the breaker stays open before letting a trial request through.
+	CoolDown time.Duration
+}
+
+type CircuitBreaker interface {
+	Provider
+	Name() string
+	State() BreakerState
+}
+
+// NewCircuitBreakerProvider decorates the provider with a circuit breaker,
+// so that a failing provider is skipped instantly instead of waiting for its timeout.
+func NewCircuitBreakerProvider(name string, provider Provider, config BreakerConfig) CircuitBreaker {
+	b := &circuitBreaker{
+		name:     name,
+		provider: provider,
+		config:   config,
+		now:      time.Now,
+	}
+	b.windowStart = b.now()
+	breakerStateMetric.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+type circuitBreaker struct {
+	name     string
+	provider Provider
+	config   BreakerConfig
+	now      func() time.Time
+
+	mu            sync.Mutex
+	state         BreakerState
+	windowStart   time.Time
+	requests      int
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+func (b *circuitBreaker) Name() string {
+	return b.name
+}
+
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *circuitBreaker) Get(ctx context.Context, location Location) (Weather, error) {
+	var weather Weather
+	err := b.call(ctx, func() (err error) {
+		weather, err = b.provider.Get(ctx, location)
+		return err
+	})
+	return weather, err
+}
+
+func (b *circuitBreaker) GetForecast(ctx context.Context, location Location, days int) (Forecast, error) {
+	forecastProvider, ok := b.provider.(ForecastProvider)
+	if !ok {
+		return Forecast{}, errors.Wrap(ErrNotSupported, b.name)
+	}
+	var forecast Forecast
+	err := b.call(ctx, func() (err error) {
+		forecast, err = forecastProvider.GetForecast(ctx, location, days)
+		return err
+	})
+	return forecast, err
+}
+
+func (b *circuitBreaker) GetHourlyForecast(ctx context.Context, location Location, hours int) (HourlyForecast, error) {
+	hourlyForecastProvider, ok := b.provider.(HourlyForecastProvider)
+	if !ok {
+		return HourlyForecast{}, errors.Wrap(ErrNotSupported, b.name)
+	}
+	var forecast HourlyForecast
+	err := b.call(ctx, func() (err error) {
+		forecast, err = hourlyForecastProvider.GetHourlyForecast(ctx, location, hours)
+		return err
+	})
+	return forecast, err
+}
+
+func (b *circuitBreaker) call(ctx context.Context, fn func() error) error {
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	err := fn()
+	if err != nil && ctx.Err() == context.Canceled {
+		// the caller gave up on the provider, e.g. a hedged request was answered by another provider
+		b.abandon()
+		return err
+	}
+	if errors.Cause(err) == ErrNotSupported {
+		// the provider does not cover the location, e.g. it is out of its country, which says nothing of its health
+		b.abandon()
+		return err
+	}
+	// a provider that does not know the location answered nevertheless
+	b.release(err == nil || errors.Cause(err) == ErrUnknownLocation)
+	return err
+}
+
+func (b *circuitBreaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if coolDownLeft := b.config.CoolDown - b.now().Sub(b.openedAt); coolDownLeft > 0 {
+			return &Error{Kind: ProvidersUnavailable, RetryAfter: coolDownLeft, Err: errors.Wrap(ErrCircuitOpen, b.name)}
+		}
+		b.setState(HalfOpen)
+		b.trialInFlight = true
+	case HalfOpen:
+		if b.trialInFlight {
+			return &Error{Kind: ProvidersUnavailable, Err: errors.Wrap(ErrCircuitOpen, b.name)}
+		}
+		b.trialInFlight = true
+	default:
+		if b.now().Sub(b.windowStart) >= b.config.Window {
+			b.resetWindow()
+		}
+	}
+	return nil
+}
+
+func (b *circuitBreaker) release(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.trialInFlight = false
+		if success {
+			b.resetWindow()
+			b.setState(Closed)
+		} else {
+			b.open()
+		}
+		return
+	}
+	if b.state == Open {
+		return
+	}
+	b.requests++
+	if success {
+		return
+	}
+	b.failures++
+	if b.requests >= b.config.MinRequests && float64(b.failures)/float64(b.requests) >= b.config.FailureRatio {
+		b.open()
+	}
+}
+
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.trialInFlight = false
+	}
+}
+
+func (b *circuitBreaker) open() {
+	b.openedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *circuitBreaker) resetWindow() {
+	b.windowStart = b.now()
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	b.state = state
+	breakerStateMetric.WithLabelValues(b.name).Set(float64(state))
+}
+
+var breakerStateMetric = registerBreakerStateMetric()
+
+func registerBreakerStateMetric() *prometheus.GaugeVec {
+	metric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "weather_reporter",
+		Name:      "provider_circuit_breaker_state",
+		Help:      "Gauge of provider circuit breaker states: 0 is closed, 1 is half-open, 2 is open.",
+	}, []string{"provider"})
+	prometheus.MustRegister(metric)
+	return metric
+}
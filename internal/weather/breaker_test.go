@@ -0,0 +1,181 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testBreakerConfig = BreakerConfig{
+	FailureRatio: 0.5,
+	MinRequests:  2,
+	Window:       time.Minute,
+	CoolDown:     time.Second * 10,
+}
+
+func Test_Should_Pass_Requests_When_Breaker_Is_Closed(t *testing.T) {
+	weather := Weather{TemperatureDegrees: 1}
+	p := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	breaker := NewCircuitBreakerProvider("test", p, testBreakerConfig)
+	actualWeather, err := breaker.Get(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Open_Breaker_When_Failure_Ratio_Is_Reached(t *testing.T) {
+	calls := 0
+	p := provider(func(city string) (Weather, error) {
+		calls++
+		return Weather{}, errors.New("error-1")
+	})
+	breaker, _ := newBreakerAt(p, time.Unix(0, 0))
+
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, Closed, breaker.State())
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, Open, breaker.State())
+
+	_, err := breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, ErrCircuitOpen, errors.Cause(err))
+	assert.Equal(t, 2, calls)
+}
+
+func Test_Should_Not_Open_Breaker_Below_Failure_Ratio(t *testing.T) {
+	fail := false
+	p := provider(func(city string) (Weather, error) {
+		fail = !fail
+		if fail {
+			return Weather{}, nil
+		}
+		return Weather{}, errors.New("error-1")
+	})
+	breaker := NewCircuitBreakerProvider("test", p, BreakerConfig{FailureRatio: 0.6, MinRequests: 2, Window: time.Minute})
+	for i := 0; i < 10; i++ {
+		_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	}
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Reset_Failures_When_Window_Passes(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	breaker, now := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	*now = time.Unix(61, 0)
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Close_Breaker_When_Trial_Request_Succeeds(t *testing.T) {
+	fail := true
+	p := provider(func(city string) (Weather, error) {
+		if fail {
+			return Weather{}, errors.New("error-1")
+		}
+		return Weather{}, nil
+	})
+	breaker, now := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, Open, breaker.State())
+
+	*now = time.Unix(10, 0)
+	fail = false
+	_, err := breaker.Get(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Reopen_Breaker_When_Trial_Request_Fails(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	breaker, now := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+
+	*now = time.Unix(10, 0)
+	_, err := breaker.Get(context.Background(), Location{City: "test"})
+	assert.Contains(t, err.Error(), "error-1")
+	assert.Equal(t, Open, breaker.State())
+
+	*now = time.Unix(15, 0)
+	_, err = breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, ErrCircuitOpen, errors.Cause(err))
+}
+
+func Test_Should_Let_Single_Trial_Request_Through_When_Half_Open(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fail := true
+	p := provider(func(city string) (Weather, error) {
+		if fail {
+			return Weather{}, errors.New("error-1")
+		}
+		close(started)
+		<-release
+		return Weather{}, nil
+	})
+	breaker, now := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+
+	*now = time.Unix(10, 0)
+	fail = false
+	go func() {
+		_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	}()
+	<-started
+	assert.Equal(t, HalfOpen, breaker.State())
+	_, err := breaker.Get(context.Background(), Location{City: "test"})
+	assert.Equal(t, ErrCircuitOpen, errors.Cause(err))
+	close(release)
+}
+
+func Test_Should_Not_Count_Cancelled_Requests_As_Failures(t *testing.T) {
+	p := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		return Weather{}, ctx.Err()
+	})
+	breaker, _ := newBreakerAt(p, time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 10; i++ {
+		_, _ = breaker.Get(ctx, Location{City: "test"})
+	}
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Not_Count_Unsupported_Lookups_As_Failures(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.Wrap(ErrNotSupported, "test")
+	})
+	breaker, _ := newBreakerAt(p, time.Unix(0, 0))
+	for i := 0; i < 10; i++ {
+		_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	}
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func Test_Should_Not_Count_Unknown_Locations_As_Failures(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.Wrap(ErrUnknownLocation, "test")
+	})
+	breaker, _ := newBreakerAt(p, time.Unix(0, 0))
+	for i := 0; i < 10; i++ {
+		_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	}
+	assert.Equal(t, Closed, breaker.State())
+}
+
+func newBreakerAt(p Provider, now time.Time) (*circuitBreaker, *time.Time) {
+	breaker := NewCircuitBreakerProvider("test", p, testBreakerConfig).(*circuitBreaker)
+	breaker.now = func() time.Time { return now }
+	breaker.windowStart = now
+	return breaker, &now
+}
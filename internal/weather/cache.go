@@ -3,46 +3,160 @@ package weather
 import (
 	impl "github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
+	"strings"
 	"time"
 )
 
+// Freshness describes how usable a cached weather entry is.
+type Freshness int
+
+const (
+	// Missing means there is no entry for the location, or it outlived the stale TTL.
+	Missing Freshness = iota
+	// Fresh entries can be served without asking providers.
+	Fresh
+	// Revalidate entries can be served while they are refreshed in background.
+	Revalidate
+	// Stale entries can only be served when every provider failed.
+	Stale
+)
+
+func (f Freshness) String() string {
+	switch f {
+	case Fresh:
+		return "fresh"
+	case Revalidate:
+		return "revalidate"
+	case Stale:
+		return "stale"
+	default:
+		return "missing"
+	}
+}
+
 type Cache interface {
-	Get(city string) (Weather, bool)
-	Put(city string, weather Weather)
+	Get(location Location) (Weather, Freshness)
+	Put(location Location, weather Weather)
 }
 
-func NewWeatherCache(expiration time.Duration) Cache {
-	return &cache{
-		cache:  impl.New(expiration, expiration/2),
-		metric: registerCacheMetric(),
+// NewWeatherCache creates a cache where entries younger than freshTTL are served directly,
+// entries younger than revalidateTTL are served while being refreshed in background
+// and entries younger than staleTTL are kept to be served if all providers are down.
+func NewWeatherCache(freshTTL time.Duration, revalidateTTL time.Duration, staleTTL time.Duration) Cache {
+	return newEntryCache[Weather]("weather", freshTTL, revalidateTTL, staleTTL)
+}
+
+// entryCache is a cache of values of a single type, e.g. Weather or Forecast.
+type entryCache[T any] struct {
+	*cache
+}
+
+func newEntryCache[T any](resource string, freshTTL time.Duration, revalidateTTL time.Duration, staleTTL time.Duration) *entryCache[T] {
+	return &entryCache[T]{newCache(resource, freshTTL, revalidateTTL, staleTTL)}
+}
+
+func (c *entryCache[T]) Get(location Location) (T, Freshness) {
+	value, freshness := c.get(location)
+	if freshness == Missing {
+		var missing T
+		return missing, Missing
 	}
+	return value.(T), freshness
 }
 
+func (c *entryCache[T]) Put(location Location, value T) {
+	c.put(location, value)
+}
+
+// cache keeps values of a resource per location along with the time they were stored.
 type cache struct {
-	cache  *impl.Cache
-	metric *prometheus.CounterVec
+	resource      string
+	cache         *impl.Cache
+	freshTTL      time.Duration
+	revalidateTTL time.Duration
+	now           func() time.Time
+}
+
+type cacheEntry struct {
+	value    interface{}
+	storedAt time.Time
 }
 
-func (c *cache) Get(city string) (Weather, bool) {
-	weather, found := c.cache.Get(city)
-	if found {
-		c.metric.WithLabelValues("hit").Inc()
-		return weather.(Weather), true
+func newCache(resource string, freshTTL time.Duration, revalidateTTL time.Duration, staleTTL time.Duration) *cache {
+	if revalidateTTL < freshTTL {
+		revalidateTTL = freshTTL
 	}
-	c.metric.WithLabelValues("miss").Inc()
-	return Weather{}, false
+	if staleTTL < revalidateTTL {
+		staleTTL = revalidateTTL
+	}
+	return &cache{
+		resource:      resource,
+		cache:         impl.New(staleTTL, staleTTL/2),
+		freshTTL:      freshTTL,
+		revalidateTTL: revalidateTTL,
+		now:           time.Now,
+	}
+}
+
+// cachedValue is a value looked up in a cache along with its age and the time it is fresh for.
+type cachedValue struct {
+	value     interface{}
+	freshness Freshness
+	age       time.Duration
+	freshTTL  time.Duration
 }
 
-func (c *cache) Put(city string, weather Weather) {
-	c.cache.Set(city, weather, impl.DefaultExpiration)
+// agedCache is a capability of the caches built on cache to report the age of their values.
+type agedCache interface {
+	lookup(location Location) cachedValue
 }
 
+func (c *cache) get(location Location) (interface{}, Freshness) {
+	cached := c.lookup(location)
+	return cached.value, cached.freshness
+}
+
+func (c *cache) lookup(location Location) cachedValue {
+	item, found := c.cache.Get(cacheKey(location))
+	if !found {
+		cacheMetric.WithLabelValues(c.resource, "miss").Inc()
+		return cachedValue{freshness: Missing, freshTTL: c.freshTTL}
+	}
+	entry := item.(cacheEntry)
+	cached := cachedValue{value: entry.value, age: c.now().Sub(entry.storedAt), freshTTL: c.freshTTL}
+	switch {
+	case cached.age < c.freshTTL:
+		cacheMetric.WithLabelValues(c.resource, "hit").Inc()
+		cached.freshness = Fresh
+	case cached.age < c.revalidateTTL:
+		cacheMetric.WithLabelValues(c.resource, "revalidate").Inc()
+		cached.freshness = Revalidate
+	default:
+		cacheMetric.WithLabelValues(c.resource, "stale").Inc()
+		cached.freshness = Stale
+	}
+	return cached
+}
+
+func (c *cache) put(location Location, value interface{}) {
+	c.cache.Set(cacheKey(location), cacheEntry{value: value, storedAt: c.now()}, impl.DefaultExpiration)
+}
+
+func cacheKey(location Location) string {
+	if location.ID != "" {
+		return location.ID
+	}
+	return strings.ToLower(strings.TrimSpace(location.String()))
+}
+
+var cacheMetric = registerCacheMetric()
+
 func registerCacheMetric() *prometheus.CounterVec {
 	metric := prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "weather_reporter",
 		Name:      "cache",
-		Help:      "Counter of cache hits or misses.",
-	}, []string{"state"})
+		Help:      "Counter of cache hits, revalidate hits, stale hits or misses.",
+	}, []string{"resource", "state"})
 	prometheus.MustRegister(metric)
 	return metric
 }
@@ -0,0 +1,56 @@
+package weather
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Return_Missing_When_City_Is_Not_Cached(t *testing.T) {
+	c := NewWeatherCache(time.Second, time.Second, time.Minute)
+	_, freshness := c.Get(Location{City: "test"})
+	assert.Equal(t, Missing, freshness)
+}
+
+func Test_Should_Return_Fresh_Weather_Within_Fresh_TTL(t *testing.T) {
+	c := newCacheAt(time.Unix(0, 0))
+	weather := Weather{TemperatureDegrees: 1}
+	c.Put(Location{City: "test"}, weather)
+	c.now = func() time.Time { return time.Unix(2, 0) }
+	actualWeather, freshness := c.Get(Location{City: "test"})
+	assert.Equal(t, Fresh, freshness)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Return_Revalidate_Weather_After_Fresh_TTL(t *testing.T) {
+	c := newCacheAt(time.Unix(0, 0))
+	weather := Weather{TemperatureDegrees: 1}
+	c.Put(Location{City: "test"}, weather)
+	c.now = func() time.Time { return time.Unix(3, 0) }
+	actualWeather, freshness := c.Get(Location{City: "test"})
+	assert.Equal(t, Revalidate, freshness)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Return_Stale_Weather_After_Revalidate_TTL(t *testing.T) {
+	c := newCacheAt(time.Unix(0, 0))
+	weather := Weather{TemperatureDegrees: 1}
+	c.Put(Location{City: "test"}, weather)
+	c.now = func() time.Time { return time.Unix(10, 0) }
+	actualWeather, freshness := c.Get(Location{City: "test"})
+	assert.Equal(t, Stale, freshness)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Normalize_City_Cache_Key(t *testing.T) {
+	c := NewWeatherCache(time.Minute, time.Minute, time.Minute)
+	c.Put(Location{City: " Sydney"}, Weather{TemperatureDegrees: 1})
+	_, freshness := c.Get(Location{City: "sydney"})
+	assert.Equal(t, Fresh, freshness)
+}
+
+func newCacheAt(now time.Time) *entryCache[Weather] {
+	c := NewWeatherCache(time.Second*3, time.Second*10, time.Minute).(*entryCache[Weather])
+	c.now = func() time.Time { return now }
+	return c
+}
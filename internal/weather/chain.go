@@ -0,0 +1,226 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// providerCall asks a single provider for a resource of a location.
+type providerCall func(ctx context.Context, provider Provider, location Location) (interface{}, error)
+
+// valueCache is a cache of a single resource, e.g. current weather or forecasts.
+type valueCache interface {
+	get(location Location) cachedValue
+	put(location Location, value interface{})
+}
+
+// providerChain looks resources up from the cache and the providers, with coalescing,
+// background refreshes, failover and stale fallbacks shared by every resource.
+type providerChain struct {
+	resource      string
+	providers     []Provider
+	cache         valueCache
+	coalescer     *coalescer
+	refresher     *refresher
+	hedgeDelay    time.Duration
+	requestBudget time.Duration
+	resolver      Resolver
+}
+
+func newProviderChain(resource string, cache valueCache, config ServiceConfig, providers []Provider) *providerChain {
+	return &providerChain{
+		resource:      resource,
+		providers:     providers,
+		cache:         cache,
+		coalescer:     newCoalescer(),
+		refresher:     newRefresher(config.RefreshConcurrency, config.RefreshMaxAttempts),
+		hedgeDelay:    config.HedgeDelay,
+		requestBudget: config.RequestBudget,
+		resolver:      config.Resolver,
+	}
+}
+
+func (c *providerChain) get(ctx context.Context, location Location, call providerCall) (interface{}, Metadata, error) {
+	log.WithField("location", location.String()).WithField("resource", c.resource).Debug("searching for " + c.resource)
+	if c.resolver != nil {
+		resolved, err := c.resolver.Resolve(location)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		location = resolved
+	}
+	key := cacheKey(location)
+	cached := c.cache.get(location)
+	if cached.freshness == Fresh {
+		return cached.value, Metadata{Age: cached.age, MaxAge: cached.freshTTL - cached.age}, nil
+	}
+	if cached.freshness == Revalidate && c.refresher.canRefresh(key) {
+		c.refresher.refresh(key, func() error {
+			_, err := c.fetch(context.Background(), key, location, call)
+			return err
+		})
+		return cached.value, Metadata{Age: cached.age}, nil
+	}
+	value, err := c.fetch(ctx, key, location, call)
+	if err == nil {
+		return value, Metadata{MaxAge: cached.freshTTL}, nil
+	}
+	err = errors.Wrapf(err, "failed to get %v %v from providers", location, c.resource)
+	if cached.freshness != Missing {
+		log.WithField("location", location.String()).
+			WithField("resource", c.resource).
+			WithField("error", fmt.Sprintf("%+v", err)).
+			Warn("failed to get " + c.resource + " from provider; cached result will be returned")
+		return cached.value, Metadata{Age: cached.age, Stale: true}, nil
+	}
+	if errors.Cause(err) == ErrUnknownLocation {
+		return nil, Metadata{}, errors.Wrapf(ErrUnknownLocation, "%v", location)
+	}
+	return nil, Metadata{}, err
+}
+
+func (c *providerChain) fetch(ctx context.Context, key string, location Location, call providerCall) (interface{}, error) {
+	if c.requestBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestBudget)
+		defer cancel()
+	}
+	return c.coalescer.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		value, err := c.getFromProvider(ctx, location, call)
+		if err == nil {
+			c.refresher.reset(key)
+		}
+		return value, err
+	})
+}
+
+func (c *providerChain) getFromProvider(ctx context.Context, location Location, call providerCall) (interface{}, error) {
+	if len(c.providers) == 0 {
+		return nil, errors.New("no providers configured")
+	}
+	if c.hedgeDelay > 0 {
+		return c.getFromHedgedProviders(ctx, location, call)
+	}
+	var providerErrors []error
+	for i, currentProvider := range c.providers {
+		if ctx.Err() != nil {
+			err := errors.Wrapf(ctx.Err(), "failed to get %v %v from provider", location, c.resource)
+			return nil, providersError(err, append(providerErrors, ctx.Err()))
+		}
+		providerCtx, cancel := withProviderBudget(ctx, len(c.providers)-i)
+		value, err := call(providerCtx, currentProvider, location)
+		cancel()
+		if err == nil {
+			c.cache.put(location, value)
+			return value, nil
+		}
+		c.logProviderError(location, err)
+		providerErrors = append(providerErrors, err)
+	}
+	lastError := errors.Wrapf(providerErrors[len(providerErrors)-1], "failed to get %v %v from provider", location, c.resource)
+	return nil, providersError(lastError, providerErrors)
+}
+
+// withProviderBudget gives a provider an equal share of the time left until the context deadline,
+// so that a slow provider can not use up the time of the providers after it.
+func withProviderBudget(ctx context.Context, providersLeft int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || providersLeft <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(providersLeft))
+}
+
+type providerResult struct {
+	value interface{}
+	err   error
+}
+
+// getFromHedgedProviders calls providers in order, starting the next one either when the previous one
+// failed or when it has not answered within the hedge delay, and returns the first successful result.
+// Providers still running once a result is returned are cancelled, so that they release their connections
+// and their circuit breakers do not count them as failed.
+func (c *providerChain) getFromHedgedProviders(ctx context.Context, location Location, call providerCall) (interface{}, error) {
+	results := make(chan providerResult, len(c.providers))
+	cancels := make([]context.CancelFunc, 0, len(c.providers))
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	hedge := time.NewTimer(c.hedgeDelay)
+	defer hedge.Stop()
+	startNext := func() {
+		currentProvider := c.providers[len(cancels)]
+		providerCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			value, err := call(providerCtx, currentProvider, location)
+			results <- providerResult{value: value, err: err}
+		}()
+		if !hedge.Stop() {
+			select {
+			case <-hedge.C:
+			default:
+			}
+		}
+		if len(cancels) < len(c.providers) {
+			hedge.Reset(c.hedgeDelay)
+		}
+	}
+
+	startNext()
+	pending := 1
+	var providerErrors []error
+	for pending > 0 {
+		select {
+		case <-hedge.C:
+			log.WithField("location", location.String()).
+				WithField("resource", c.resource).
+				Debug("provider is slow; hedging request to the next provider")
+			hedgedMetric.Inc()
+			startNext()
+			pending++
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				c.cache.put(location, result.value)
+				return result.value, nil
+			}
+			c.logProviderError(location, result.err)
+			providerErrors = append(providerErrors, result.err)
+			if len(cancels) < len(c.providers) && ctx.Err() == nil {
+				startNext()
+				pending++
+			}
+		}
+	}
+	lastError := errors.Wrapf(providerErrors[len(providerErrors)-1], "failed to get %v %v from provider", location, c.resource)
+	return nil, providersError(lastError, providerErrors)
+}
+
+func (c *providerChain) logProviderError(location Location, err error) {
+	if cause := errors.Cause(err); cause == ErrNotSupported || cause == ErrUnknownLocation {
+		return
+	}
+	log.WithField("location", location.String()).
+		WithField("resource", c.resource).
+		WithField("error", err).
+		Warn("failed to get " + c.resource + " from provider")
+}
+
+var hedgedMetric = registerHedgedMetric()
+
+func registerHedgedMetric() prometheus.Counter {
+	metric := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "weather_reporter",
+		Name:      "hedged_requests_total",
+		Help:      "Counter of provider requests started because the previous provider did not answer within the hedge delay.",
+	})
+	prometheus.MustRegister(metric)
+	return metric
+}
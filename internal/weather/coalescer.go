@@ -0,0 +1,99 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+// coalescer deduplicates concurrent lookups of the same key,
+// so that all callers waiting for a key share the result of a single call.
+// The shared call is cancelled once every caller waiting for it has gone.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   interface{}
+	err     error
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{calls: make(map[string]*call)}
+}
+
+func (c *coalescer) do(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	current, found := c.calls[key]
+	if found {
+		coalescedMetric.Inc()
+		current.waiters++
+	} else {
+		current = c.start(ctx, key, fn)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-current.done:
+		return current.value, current.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		current.waiters--
+		if current.waiters == 0 {
+			current.cancel()
+			c.remove(key, current)
+		}
+		c.mu.Unlock()
+		return nil, errors.Wrap(ctx.Err(), "lookup abandoned")
+	}
+}
+
+func (c *coalescer) start(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) *call {
+	// the shared call must outlive the caller that started it, but not its deadline
+	var callCtx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := ctx.Deadline(); ok {
+		callCtx, cancel = context.WithDeadline(context.Background(), deadline)
+	} else {
+		callCtx, cancel = context.WithCancel(context.Background())
+	}
+	current := &call{done: make(chan struct{}), cancel: cancel, waiters: 1}
+	c.calls[key] = current
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				current.err = errors.Errorf("lookup panicked: %v", r)
+			}
+			c.mu.Lock()
+			c.remove(key, current)
+			c.mu.Unlock()
+			cancel()
+			close(current.done)
+		}()
+		current.value, current.err = fn(callCtx)
+	}()
+	return current
+}
+
+func (c *coalescer) remove(key string, current *call) {
+	if c.calls[key] == current {
+		delete(c.calls, key)
+	}
+}
+
+var coalescedMetric = registerCoalescedMetric()
+
+func registerCoalescedMetric() prometheus.Counter {
+	metric := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "weather_reporter",
+		Name:      "coalesced_requests_total",
+		Help:      "Counter of lookups that joined an in-flight provider call instead of making their own.",
+	})
+	prometheus.MustRegister(metric)
+	return metric
+}
@@ -0,0 +1,139 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrorKind tells callers why a lookup failed.
+type ErrorKind int
+
+const (
+	// Internal is any failure not caused by the caller or the providers.
+	Internal ErrorKind = iota
+	// InvalidInput is a lookup with invalid parameters, e.g. a malformed city or coordinates.
+	InvalidInput
+	// UnknownLocation is a lookup of a place that does not exist.
+	UnknownLocation
+	// ProvidersFailed is a lookup every provider answered with an error.
+	ProvidersFailed
+	// ProvidersUnavailable is a lookup no provider could answer, e.g. as their circuit breakers are open.
+	ProvidersUnavailable
+	// RateLimited is a lookup providers refused because of too many requests.
+	RateLimited
+)
+
+// Error is an error of a kind, which is worth retrying after RetryAfter when it is set.
+// Its cause is the cause of the wrapped error, so that sentinel errors are still found by errors.Cause.
+type Error struct {
+	Kind       ErrorKind
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Cause() error {
+	return e.Err
+}
+
+// invalidInputf formats an InvalidInput error.
+func invalidInputf(format string, args ...interface{}) error {
+	return &Error{Kind: InvalidInput, Err: errors.Errorf(format, args...)}
+}
+
+type causer interface {
+	Cause() error
+}
+
+// AsError returns the first Error in the chain of causes of the error, or an Internal error when there is none.
+func AsError(err error) *Error {
+	original := err
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			return e
+		}
+		if errors.Cause(err) == ErrUnknownLocation {
+			return &Error{Kind: UnknownLocation, Err: err}
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return &Error{Kind: Internal, Err: original}
+}
+
+// ErrorDetail describes the error to callers. Only the messages of errors caused by callers are passed through,
+// other errors are described by their kind, not to leak internals like provider URLs.
+func ErrorDetail(err error) string {
+	e := AsError(err)
+	switch e.Kind {
+	case InvalidInput, UnknownLocation:
+		return e.Error()
+	case ProvidersFailed:
+		return "all weather providers failed"
+	case ProvidersUnavailable:
+		return "no weather provider is available"
+	case RateLimited:
+		return "weather providers are rate limited"
+	default:
+		return "internal error"
+	}
+}
+
+// providersError classifies the errors of every provider of a lookup. A location is unknown when providers
+// either do not know it or do not support it. A lookup failed when any provider answered with an error,
+// otherwise it is worth retrying after the earliest time a provider may answer again.
+func providersError(err error, providerErrors []error) error {
+	if unknown := unknownToProviders(providerErrors); unknown != nil {
+		return &Error{Kind: UnknownLocation, Err: unknown}
+	}
+	result := &Error{Kind: ProvidersUnavailable, Err: err}
+	for _, providerError := range providerErrors {
+		e := AsError(providerError)
+		switch {
+		case e.Kind == RateLimited || e.Kind == ProvidersUnavailable:
+			if e.Kind == RateLimited && result.Kind == ProvidersUnavailable {
+				result.Kind = RateLimited
+			}
+			if e.RetryAfter > 0 && (result.RetryAfter == 0 || e.RetryAfter < result.RetryAfter) {
+				result.RetryAfter = e.RetryAfter
+			}
+		case isUnavailable(errors.Cause(providerError)):
+		default:
+			result.Kind = ProvidersFailed
+		}
+	}
+	if result.Kind == ProvidersFailed {
+		result.RetryAfter = 0
+	}
+	return result
+}
+
+// unknownToProviders returns the error of the first provider that did not know the location,
+// or nil when any provider failed otherwise.
+func unknownToProviders(providerErrors []error) error {
+	var unknown error
+	for _, providerError := range providerErrors {
+		switch errors.Cause(providerError) {
+		case ErrUnknownLocation:
+			if unknown == nil {
+				unknown = providerError
+			}
+		case ErrNotSupported:
+		default:
+			return nil
+		}
+	}
+	return unknown
+}
+
+// isUnavailable tells whether the provider could not answer rather than failed.
+func isUnavailable(cause error) bool {
+	return cause == ErrNotSupported || cause == context.DeadlineExceeded || cause == context.Canceled
+}
@@ -0,0 +1,73 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Find_Error_Kind_In_Chain_Of_Causes(t *testing.T) {
+	err := errors.Wrap(&Error{Kind: RateLimited, RetryAfter: time.Second, Err: errors.New("error-1")}, "wrapped")
+	assert.Equal(t, RateLimited, AsError(err).Kind)
+	assert.Equal(t, time.Second, AsError(err).RetryAfter)
+	assert.Equal(t, Internal, AsError(errors.New("error-1")).Kind)
+	assert.Equal(t, UnknownLocation, AsError(errors.Wrap(ErrUnknownLocation, "atlantis")).Kind)
+}
+
+func Test_Should_Keep_Cause_Of_Wrapped_Error(t *testing.T) {
+	err := &Error{Kind: ProvidersUnavailable, Err: errors.Wrap(ErrCircuitOpen, "test")}
+	assert.Equal(t, ErrCircuitOpen, errors.Cause(err))
+}
+
+func Test_Should_Describe_Errors_Without_Internals(t *testing.T) {
+	assert.Equal(t, "internal error", ErrorDetail(errors.New("http://provider?appid=secret")))
+	assert.Equal(t, "all weather providers failed", ErrorDetail(&Error{Kind: ProvidersFailed, Err: errors.New("secret")}))
+	assert.Equal(t, "days must be from 1 to 5", ErrorDetail(invalidInputf("days must be from 1 to %v", 5)))
+}
+
+func Test_Should_Classify_Provider_Errors(t *testing.T) {
+	failed := errors.New("error-1")
+	open := &Error{Kind: ProvidersUnavailable, RetryAfter: 10 * time.Second, Err: ErrCircuitOpen}
+	limited := &Error{Kind: RateLimited, RetryAfter: 5 * time.Second, Err: errors.New("429")}
+	for _, test := range []struct {
+		errs       []error
+		kind       ErrorKind
+		retryAfter time.Duration
+	}{
+		{[]error{failed, open}, ProvidersFailed, 0},
+		{[]error{open, limited}, RateLimited, 5 * time.Second},
+		{[]error{open, ErrNotSupported}, ProvidersUnavailable, 10 * time.Second},
+		{[]error{errors.Wrap(context.DeadlineExceeded, "timeout")}, ProvidersUnavailable, 0},
+		{[]error{errors.Wrap(ErrUnknownLocation, "atlantis"), ErrNotSupported}, UnknownLocation, 0},
+		{[]error{errors.Wrap(ErrUnknownLocation, "atlantis"), failed}, ProvidersFailed, 0},
+	} {
+		e := AsError(providersError(errors.New("last"), test.errs))
+		assert.Equal(t, test.kind, e.Kind, "%v", test.errs)
+		assert.Equal(t, test.retryAfter, e.RetryAfter, "%v", test.errs)
+	}
+}
+
+func Test_Should_Return_Providers_Failed_When_All_Providers_Failed(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	service := NewWeatherService(NewWeatherCache(0, 0, 0), ServiceConfig{}, p)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.Equal(t, ProvidersFailed, AsError(err).Kind)
+}
+
+func Test_Should_Return_Providers_Unavailable_With_Cool_Down_When_Circuits_Are_Open(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	breaker, now := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	_, _ = breaker.Get(context.Background(), Location{City: "test"})
+	*now = now.Add(testBreakerConfig.CoolDown / 2)
+	service := NewWeatherService(NewWeatherCache(0, 0, 0), ServiceConfig{}, breaker)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.Equal(t, ProvidersUnavailable, AsError(err).Kind)
+	assert.Equal(t, testBreakerConfig.CoolDown/2, AsError(err).RetryAfter)
+}
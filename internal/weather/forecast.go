@@ -0,0 +1,119 @@
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// MaxForecastDays is the number of days providers forecast.
+const MaxForecastDays = 5
+
+// ForecastProvider is a capability of a Provider to forecast daily weather.
+type ForecastProvider interface {
+	GetForecast(ctx context.Context, location Location, days int) (Forecast, error)
+}
+
+// Forecast is the unified daily forecast, starting from the current day of the city.
+type Forecast struct {
+	Days  []DailyForecast `json:"days"`
+	Units Units           `json:"units"`
+	// Provider is the name of the provider that served the forecast.
+	Provider string `json:"provider,omitempty"`
+}
+
+type DailyForecast struct {
+	// Date is the local date of the city in YYYY-MM-DD format.
+	Date           string  `json:"date"`
+	TemperatureMin float64 `json:"temperature_min"`
+	TemperatureMax float64 `json:"temperature_max"`
+	// WindSpeed is the maximum wind speed of the day.
+	WindSpeed *float64   `json:"wind_speed,omitempty"`
+	Condition *Condition `json:"condition,omitempty"`
+}
+
+// Limit returns the forecast of the first days only.
+func (f Forecast) Limit(days int) Forecast {
+	limited := f
+	if days < len(f.Days) {
+		limited.Days = f.Days[:days]
+	}
+	return limited
+}
+
+// Convert returns the forecast with its measurements converted to the units.
+func (f Forecast) Convert(units Units) Forecast {
+	converted := f
+	converted.Days = make([]DailyForecast, len(f.Days))
+	for i, day := range f.Days {
+		day.TemperatureMin = ConvertTemperature(day.TemperatureMin, f.Units.Temperature, units.Temperature)
+		day.TemperatureMax = ConvertTemperature(day.TemperatureMax, f.Units.Temperature, units.Temperature)
+		if day.WindSpeed != nil {
+			windSpeed := ConvertSpeed(*day.WindSpeed, f.Units.WindSpeed, units.WindSpeed)
+			day.WindSpeed = &windSpeed
+		}
+		converted.Days[i] = day
+	}
+	converted.Units = units.orCanonical()
+	return converted
+}
+
+// Round returns the forecast with its measurements rounded to the number of decimal places.
+func (f Forecast) Round(precision int) Forecast {
+	rounded := f
+	rounded.Days = make([]DailyForecast, len(f.Days))
+	for i, day := range f.Days {
+		day.TemperatureMin = round(day.TemperatureMin, precision)
+		day.TemperatureMax = round(day.TemperatureMax, precision)
+		day.WindSpeed = roundOptional(day.WindSpeed, precision)
+		rounded.Days[i] = day
+	}
+	return rounded
+}
+
+type ForecastCache interface {
+	Get(location Location) (Forecast, Freshness)
+	Put(location Location, forecast Forecast)
+}
+
+// NewForecastCache creates a forecast cache with the same semantics as NewWeatherCache.
+func NewForecastCache(freshTTL time.Duration, revalidateTTL time.Duration, staleTTL time.Duration) ForecastCache {
+	return newEntryCache[Forecast]("forecast", freshTTL, revalidateTTL, staleTTL)
+}
+
+type ForecastService interface {
+	GetForecast(ctx context.Context, location Location, days int) (Forecast, Metadata, error)
+}
+
+// NewForecastService creates a service looking forecasts up from providers with ForecastProvider capability,
+// with the same caching and failover as the weather service.
+func NewForecastService(cache ForecastCache, config ServiceConfig, providers ...Provider) ForecastService {
+	return &forecastService{
+		chain: newProviderChain("forecast", &cacheAdapter[Forecast]{cache}, config, providers),
+	}
+}
+
+type forecastService struct {
+	chain *providerChain
+}
+
+func (s *forecastService) GetForecast(ctx context.Context, location Location, days int) (Forecast, Metadata, error) {
+	if days < 1 || days > MaxForecastDays {
+		return Forecast{}, Metadata{}, invalidInputf("days must be from 1 to %v", MaxForecastDays)
+	}
+	value, metadata, err := s.chain.get(ctx, location, getForecast)
+	if err != nil {
+		return Forecast{}, Metadata{}, err
+	}
+	forecast := value.(Forecast)
+	metadata.Provider = forecast.Provider
+	return forecast.Limit(days), metadata, nil
+}
+
+// getForecast always asks providers for all days, so that a single cache entry serves any number of days.
+func getForecast(ctx context.Context, provider Provider, location Location) (interface{}, error) {
+	forecastProvider, ok := provider.(ForecastProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return forecastProvider.GetForecast(ctx, location, MaxForecastDays)
+}
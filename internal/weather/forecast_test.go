@@ -0,0 +1,138 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Return_Forecast_From_Provider_Limited_To_Days(t *testing.T) {
+	p := forecastProvider(func(city string, days int) (Forecast, error) {
+		assert.Equal(t, MaxForecastDays, days)
+		return testForecast(5), nil
+	})
+	service := NewForecastService(NewForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p)
+	forecast, _, err := service.GetForecast(context.Background(), Location{City: "test"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, testForecast(2), forecast)
+}
+
+func Test_Should_Skip_Providers_Without_Forecast_Capability(t *testing.T) {
+	p1 := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	p2 := forecastProvider(func(city string, days int) (Forecast, error) {
+		return testForecast(5), nil
+	})
+	service := NewForecastService(NewForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p1, p2)
+	forecast, _, err := service.GetForecast(context.Background(), Location{City: "test"}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, testForecast(5), forecast)
+}
+
+func Test_Should_Return_Error_When_No_Provider_Has_Forecast_Capability(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	service := NewForecastService(NewForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p)
+	_, _, err := service.GetForecast(context.Background(), Location{City: "test"}, 5)
+	assert.Equal(t, ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Serve_Fresh_Forecast_From_Cache(t *testing.T) {
+	calls := 0
+	p := forecastProvider(func(city string, days int) (Forecast, error) {
+		calls++
+		return testForecast(5), nil
+	})
+	service := NewForecastService(NewForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p)
+	_, _, _ = service.GetForecast(context.Background(), Location{City: "test"}, 5)
+	forecast, _, err := service.GetForecast(context.Background(), Location{City: "Test"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, testForecast(3), forecast)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Should_Return_Stale_Forecast_When_All_Providers_Failed(t *testing.T) {
+	fail := false
+	p := forecastProvider(func(city string, days int) (Forecast, error) {
+		if fail {
+			return Forecast{}, errors.New("error-1")
+		}
+		return testForecast(5), nil
+	})
+	service := NewForecastService(NewForecastCache(0, 0, time.Minute), ServiceConfig{}, p)
+	_, _, _ = service.GetForecast(context.Background(), Location{City: "test"}, 5)
+	fail = true
+	forecast, _, err := service.GetForecast(context.Background(), Location{City: "test"}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, testForecast(5), forecast)
+}
+
+func Test_Should_Return_Error_For_Invalid_Forecast_Days(t *testing.T) {
+	service := NewForecastService(NewForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{})
+	_, _, err := service.GetForecast(context.Background(), Location{City: "test"}, 0)
+	assert.Contains(t, err.Error(), "days must be")
+	_, _, err = service.GetForecast(context.Background(), Location{City: "test"}, MaxForecastDays+1)
+	assert.Contains(t, err.Error(), "days must be")
+}
+
+func Test_Should_Convert_And_Round_Forecast(t *testing.T) {
+	windSpeed := 36.0
+	forecast := Forecast{
+		Days:  []DailyForecast{{Date: "2018-10-22", TemperatureMin: 10, TemperatureMax: 25, WindSpeed: &windSpeed}},
+		Units: CanonicalUnits,
+	}
+	converted := forecast.Convert(Units{WindSpeed: MilesPerHour, Temperature: Fahrenheit}).Round(1)
+	assert.Equal(t, 50.0, converted.Days[0].TemperatureMin)
+	assert.Equal(t, 77.0, converted.Days[0].TemperatureMax)
+	assert.Equal(t, 22.4, *converted.Days[0].WindSpeed)
+	assert.Equal(t, Units{WindSpeed: MilesPerHour, Temperature: Fahrenheit}, converted.Units)
+	assert.Equal(t, 36.0, *forecast.Days[0].WindSpeed)
+}
+
+func Test_Should_Return_Not_Supported_From_Breaker_For_Provider_Without_Forecast(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	breaker := NewCircuitBreakerProvider("test", p, testBreakerConfig)
+	_, err := breaker.(ForecastProvider).GetForecast(context.Background(), Location{City: "test"}, 5)
+	assert.Equal(t, ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Open_Breaker_On_Forecast_Failures(t *testing.T) {
+	p := forecastProvider(func(city string, days int) (Forecast, error) {
+		return Forecast{}, errors.New("error-1")
+	})
+	breaker, _ := newBreakerAt(p, time.Unix(0, 0))
+	_, _ = breaker.GetForecast(context.Background(), Location{City: "test"}, 5)
+	_, _ = breaker.GetForecast(context.Background(), Location{City: "test"}, 5)
+	assert.Equal(t, Open, breaker.State())
+}
+
+func testForecast(days int) Forecast {
+	forecast := Forecast{Units: CanonicalUnits, Provider: "test"}
+	for i := 0; i < days; i++ {
+		forecast.Days = append(forecast.Days, DailyForecast{
+			Date:           time.Date(2018, 10, 22+i, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+			TemperatureMin: float64(10 + i),
+			TemperatureMax: float64(20 + i),
+		})
+	}
+	return forecast
+}
+
+type forecastProviderStub struct {
+	providerStub
+	forecastHandler func(city string, days int) (Forecast, error)
+}
+
+func (p *forecastProviderStub) GetForecast(ctx context.Context, location Location, days int) (Forecast, error) {
+	return p.forecastHandler(location.String(), days)
+}
+
+func forecastProvider(handler func(city string, days int) (Forecast, error)) Provider {
+	return &forecastProviderStub{forecastHandler: handler}
+}
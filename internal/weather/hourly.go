@@ -0,0 +1,137 @@
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// MaxForecastHours is the number of hours providers forecast hourly.
+const MaxForecastHours = 48
+
+// HourlyForecastProvider is a capability of a Provider to forecast weather per hour.
+type HourlyForecastProvider interface {
+	GetHourlyForecast(ctx context.Context, location Location, hours int) (HourlyForecast, error)
+}
+
+// HourlyForecast is the unified hourly forecast, starting from the current hour.
+type HourlyForecast struct {
+	Hours []HourlyEntry `json:"hours"`
+	// TimeZone is the UTC offset of the city local time, e.g. "+11:00".
+	TimeZone string `json:"time_zone"`
+	Units    Units  `json:"units"`
+	// Provider is the name of the provider that served the forecast.
+	Provider string `json:"provider,omitempty"`
+}
+
+type HourlyEntry struct {
+	Time time.Time `json:"time"`
+	// LocalTime is the same instant as Time in the city time zone.
+	LocalTime          time.Time `json:"local_time"`
+	TemperatureDegrees float64   `json:"temperature_degrees"`
+	WindSpeed          float64   `json:"wind_speed"`
+	// PrecipitationProbability is in percent.
+	PrecipitationProbability *float64 `json:"precipitation_probability,omitempty"`
+}
+
+// NewHourlyEntry stamps an hourly entry with the time both in UTC and in the city location.
+func NewHourlyEntry(at time.Time, location *time.Location) HourlyEntry {
+	return HourlyEntry{Time: at.UTC(), LocalTime: at.In(location)}
+}
+
+// FormatUTCOffset formats the offset in seconds east of UTC, e.g. "+11:00".
+func FormatUTCOffset(offset int) string {
+	return time.Unix(0, 0).In(time.FixedZone("", offset)).Format("-07:00")
+}
+
+// Window returns the forecast of the hours starting from the hour of the time.
+func (f HourlyForecast) Window(from time.Time, hours int) HourlyForecast {
+	windowed := f
+	windowed.Hours = nil
+	start := from.Truncate(time.Hour)
+	for _, entry := range f.Hours {
+		if len(windowed.Hours) == hours {
+			break
+		}
+		if !entry.Time.Before(start) {
+			windowed.Hours = append(windowed.Hours, entry)
+		}
+	}
+	return windowed
+}
+
+// Convert returns the forecast with its measurements converted to the units.
+func (f HourlyForecast) Convert(units Units) HourlyForecast {
+	converted := f
+	converted.Hours = make([]HourlyEntry, len(f.Hours))
+	for i, entry := range f.Hours {
+		entry.TemperatureDegrees = ConvertTemperature(entry.TemperatureDegrees, f.Units.Temperature, units.Temperature)
+		entry.WindSpeed = ConvertSpeed(entry.WindSpeed, f.Units.WindSpeed, units.WindSpeed)
+		converted.Hours[i] = entry
+	}
+	converted.Units = units.orCanonical()
+	return converted
+}
+
+// Round returns the forecast with its measurements rounded to the number of decimal places.
+func (f HourlyForecast) Round(precision int) HourlyForecast {
+	rounded := f
+	rounded.Hours = make([]HourlyEntry, len(f.Hours))
+	for i, entry := range f.Hours {
+		entry.TemperatureDegrees = round(entry.TemperatureDegrees, precision)
+		entry.WindSpeed = round(entry.WindSpeed, precision)
+		entry.PrecipitationProbability = roundOptional(entry.PrecipitationProbability, precision)
+		rounded.Hours[i] = entry
+	}
+	return rounded
+}
+
+type HourlyForecastCache interface {
+	Get(location Location) (HourlyForecast, Freshness)
+	Put(location Location, forecast HourlyForecast)
+}
+
+// NewHourlyForecastCache creates an hourly forecast cache with the same semantics as NewWeatherCache.
+func NewHourlyForecastCache(freshTTL time.Duration, revalidateTTL time.Duration, staleTTL time.Duration) HourlyForecastCache {
+	return newEntryCache[HourlyForecast]("hourly_forecast", freshTTL, revalidateTTL, staleTTL)
+}
+
+type HourlyForecastService interface {
+	GetHourlyForecast(ctx context.Context, location Location, hours int) (HourlyForecast, Metadata, error)
+}
+
+// NewHourlyForecastService creates a service looking hourly forecasts up from providers
+// with HourlyForecastProvider capability, with the same caching and failover as the weather service.
+func NewHourlyForecastService(cache HourlyForecastCache, config ServiceConfig, providers ...Provider) HourlyForecastService {
+	return &hourlyForecastService{
+		chain: newProviderChain("hourly_forecast", &cacheAdapter[HourlyForecast]{cache}, config, providers),
+		now:   time.Now,
+	}
+}
+
+type hourlyForecastService struct {
+	chain *providerChain
+	now   func() time.Time
+}
+
+func (s *hourlyForecastService) GetHourlyForecast(ctx context.Context, location Location, hours int) (HourlyForecast, Metadata, error) {
+	if hours < 1 || hours > MaxForecastHours {
+		return HourlyForecast{}, Metadata{}, invalidInputf("hours must be from 1 to %v", MaxForecastHours)
+	}
+	value, metadata, err := s.chain.get(ctx, location, getHourlyForecast)
+	if err != nil {
+		return HourlyForecast{}, Metadata{}, err
+	}
+	forecast := value.(HourlyForecast)
+	metadata.Provider = forecast.Provider
+	// a cached forecast may start before the current hour
+	return forecast.Window(s.now(), hours), metadata, nil
+}
+
+// getHourlyForecast asks providers for all hours, so that a single cache entry serves any number of hours.
+func getHourlyForecast(ctx context.Context, provider Provider, location Location) (interface{}, error) {
+	hourlyForecastProvider, ok := provider.(HourlyForecastProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return hourlyForecastProvider.GetHourlyForecast(ctx, location, MaxForecastHours)
+}
@@ -0,0 +1,105 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testHourlyStart = time.Date(2018, 10, 22, 10, 0, 0, 0, time.UTC)
+
+func Test_Should_Return_Hourly_Forecast_From_Current_Hour(t *testing.T) {
+	p := hourlyForecastProvider(func(city string, hours int) (HourlyForecast, error) {
+		assert.Equal(t, MaxForecastHours, hours)
+		return testHourlyForecast(MaxForecastHours), nil
+	})
+	service := newHourlyServiceAt(p, testHourlyStart.Add(2*time.Hour+30*time.Minute))
+	forecast, _, err := service.GetHourlyForecast(context.Background(), Location{City: "test"}, 3)
+	assert.NoError(t, err)
+	assert.Len(t, forecast.Hours, 3)
+	assert.Equal(t, testHourlyStart.Add(2*time.Hour), forecast.Hours[0].Time)
+}
+
+func Test_Should_Return_Error_When_No_Provider_Has_Hourly_Forecast_Capability(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	service := newHourlyServiceAt(p, testHourlyStart)
+	_, _, err := service.GetHourlyForecast(context.Background(), Location{City: "test"}, MaxForecastHours)
+	assert.Equal(t, ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Return_Error_For_Invalid_Forecast_Hours(t *testing.T) {
+	service := newHourlyServiceAt(nil, testHourlyStart)
+	_, _, err := service.GetHourlyForecast(context.Background(), Location{City: "test"}, 0)
+	assert.Contains(t, err.Error(), "hours must be")
+	_, _, err = service.GetHourlyForecast(context.Background(), Location{City: "test"}, MaxForecastHours+1)
+	assert.Contains(t, err.Error(), "hours must be")
+}
+
+func Test_Should_Stamp_Hourly_Entry_In_UTC_And_Local_Time(t *testing.T) {
+	location := time.FixedZone(FormatUTCOffset(11*3600), 11*3600)
+	entry := NewHourlyEntry(time.Date(2018, 10, 22, 21, 0, 0, 0, location), location)
+	assert.Equal(t, "2018-10-22T10:00:00Z", entry.Time.Format(time.RFC3339))
+	assert.Equal(t, "2018-10-22T21:00:00+11:00", entry.LocalTime.Format(time.RFC3339))
+	assert.Equal(t, "-03:30", FormatUTCOffset(-3*3600-1800))
+}
+
+func Test_Should_Convert_And_Round_Hourly_Forecast(t *testing.T) {
+	probability := 12.34
+	forecast := HourlyForecast{
+		Hours: []HourlyEntry{{TemperatureDegrees: 25, WindSpeed: 36, PrecipitationProbability: &probability}},
+		Units: CanonicalUnits,
+	}
+	converted := forecast.Convert(Units{WindSpeed: MilesPerHour, Temperature: Fahrenheit}).Round(1)
+	assert.Equal(t, 77.0, converted.Hours[0].TemperatureDegrees)
+	assert.Equal(t, 22.4, converted.Hours[0].WindSpeed)
+	assert.Equal(t, 12.3, *converted.Hours[0].PrecipitationProbability)
+	assert.Equal(t, 25.0, forecast.Hours[0].TemperatureDegrees)
+}
+
+func Test_Should_Return_Not_Supported_From_Breaker_For_Provider_Without_Hourly_Forecast(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	breaker := NewCircuitBreakerProvider("test", p, testBreakerConfig)
+	_, err := breaker.(HourlyForecastProvider).GetHourlyForecast(context.Background(), Location{City: "test"}, MaxForecastHours)
+	assert.Equal(t, ErrNotSupported, errors.Cause(err))
+}
+
+func newHourlyServiceAt(p Provider, now time.Time) HourlyForecastService {
+	var providers []Provider
+	if p != nil {
+		providers = append(providers, p)
+	}
+	service := NewHourlyForecastService(NewHourlyForecastCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, providers...)
+	service.(*hourlyForecastService).now = func() time.Time {
+		return now
+	}
+	return service
+}
+
+func testHourlyForecast(hours int) HourlyForecast {
+	forecast := HourlyForecast{TimeZone: "+00:00", Units: CanonicalUnits, Provider: "test"}
+	for i := 0; i < hours; i++ {
+		entry := NewHourlyEntry(testHourlyStart.Add(time.Duration(i)*time.Hour), time.UTC)
+		entry.TemperatureDegrees = float64(10 + i)
+		forecast.Hours = append(forecast.Hours, entry)
+	}
+	return forecast
+}
+
+type hourlyForecastProviderStub struct {
+	providerStub
+	hourlyForecastHandler func(city string, hours int) (HourlyForecast, error)
+}
+
+func (p *hourlyForecastProviderStub) GetHourlyForecast(ctx context.Context, location Location, hours int) (HourlyForecast, error) {
+	return p.hourlyForecastHandler(location.String(), hours)
+}
+
+func hourlyForecastProvider(handler func(city string, hours int) (HourlyForecast, error)) Provider {
+	return &hourlyForecastProviderStub{hourlyForecastHandler: handler}
+}
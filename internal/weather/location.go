@@ -0,0 +1,87 @@
+package weather
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Location is a place to look weather up for, either a city, optionally qualified by its country,
+// or coordinates.
+type Location struct {
+	// ID is the canonical identifier of a place resolved by a Resolver, e.g. "geonames:2147714".
+	ID   string `json:"id,omitempty"`
+	City string `json:"city,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 code of the city country, e.g. "AU".
+	Country     string       `json:"country,omitempty"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+}
+
+// ErrUnknownLocation is returned by a Resolver or a Provider for a location that is not a known place.
+var ErrUnknownLocation = errors.New("unknown location")
+
+// Resolver resolves locations given by users, which may have aliases or misspellings, to canonical places.
+type Resolver interface {
+	Resolve(location Location) (Location, error)
+}
+
+type Coordinates struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+}
+
+const maxCityLength = 100
+
+// cityPattern allows letters of any script along with spaces, dots, apostrophes and hyphens, e.g. "St. John's".
+var cityPattern = regexp.MustCompile(`^\p{L}[\p{L}\p{M} .'\-]*$`)
+
+var countryPattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// NewCityLocation validates the city and the optional country code and creates a location of them.
+func NewCityLocation(city string, country string) (Location, error) {
+	city = strings.Join(strings.Fields(city), " ")
+	if len(city) > maxCityLength || !cityPattern.MatchString(city) {
+		return Location{}, invalidInputf("city must have up to %v letters, spaces, dots, apostrophes and hyphens", maxCityLength)
+	}
+	if country != "" && !countryPattern.MatchString(country) {
+		return Location{}, invalidInputf("country must be a two-letter ISO 3166-1 code, got %q", country)
+	}
+	return Location{City: city, Country: strings.ToUpper(country)}, nil
+}
+
+// ParseCityLocation parses a city optionally qualified by its country after a comma, e.g. "Sydney, AU".
+func ParseCityLocation(text string) (Location, error) {
+	separator := strings.LastIndex(text, ",")
+	if separator < 0 {
+		return NewCityLocation(text, "")
+	}
+	return NewCityLocation(text[:separator], strings.TrimSpace(text[separator+1:]))
+}
+
+// NewCoordinatesLocation validates the coordinates and creates a location of them.
+func NewCoordinatesLocation(latitude float64, longitude float64) (Location, error) {
+	if latitude < -90 || latitude > 90 {
+		return Location{}, invalidInputf("latitude must be from -90 to 90, got %v", latitude)
+	}
+	if longitude < -180 || longitude > 180 {
+		return Location{}, invalidInputf("longitude must be from -180 to 180, got %v", longitude)
+	}
+	return Location{Coordinates: &Coordinates{Latitude: latitude, Longitude: longitude}}, nil
+}
+
+// String formats the location like "Sydney, AU", or like "-33.8688,151.2093" when it has no city.
+func (l Location) String() string {
+	if l.City == "" && l.Coordinates != nil {
+		return formatCoordinate(l.Coordinates.Latitude) + "," + formatCoordinate(l.Coordinates.Longitude)
+	}
+	if l.Country != "" {
+		return l.City + ", " + l.Country
+	}
+	return l.City
+}
+
+// formatCoordinate keeps 4 decimal places, which is about 10 metres and more precise than any provider.
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
@@ -0,0 +1,65 @@
+package weather
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Should_Accept_City_Names_Of_Any_Script(t *testing.T) {
+	for _, city := range []string{"New York", "São Paulo", "St. John's", "Winston-Salem", "Zürich", "東京"} {
+		location, err := NewCityLocation(city, "")
+		assert.NoError(t, err, city)
+		assert.Equal(t, city, location.City)
+	}
+}
+
+func Test_Should_Reject_Invalid_City_Names(t *testing.T) {
+	for _, city := range []string{"", " ", "sydney\"", "123", "-sydney", strings.Repeat("a", maxCityLength+1)} {
+		_, err := NewCityLocation(city, "")
+		assert.Error(t, err, city)
+	}
+}
+
+func Test_Should_Normalize_City_Location(t *testing.T) {
+	location, err := NewCityLocation("  New   York ", "us")
+	assert.NoError(t, err)
+	assert.Equal(t, Location{City: "New York", Country: "US"}, location)
+	assert.Equal(t, "New York, US", location.String())
+}
+
+func Test_Should_Parse_Country_Qualified_City(t *testing.T) {
+	location, err := ParseCityLocation("Sydney, ca")
+	assert.NoError(t, err)
+	assert.Equal(t, Location{City: "Sydney", Country: "CA"}, location)
+	location, err = ParseCityLocation("Sydney")
+	assert.NoError(t, err)
+	assert.Equal(t, Location{City: "Sydney"}, location)
+	_, err = ParseCityLocation("Sydney, Australia")
+	assert.Error(t, err)
+}
+
+func Test_Should_Reject_Invalid_Country(t *testing.T) {
+	_, err := NewCityLocation("sydney", "AUS")
+	assert.Contains(t, err.Error(), "country must be")
+}
+
+func Test_Should_Validate_Coordinates(t *testing.T) {
+	location, err := NewCoordinatesLocation(-33.86882, 151.20929)
+	assert.NoError(t, err)
+	assert.Equal(t, "-33.8688,151.2093", location.String())
+	_, err = NewCoordinatesLocation(91, 0)
+	assert.Contains(t, err.Error(), "latitude must be")
+	_, err = NewCoordinatesLocation(0, -181)
+	assert.Contains(t, err.Error(), "longitude must be")
+}
+
+func Test_Should_Cache_Cities_Of_Different_Countries_Separately(t *testing.T) {
+	c := NewWeatherCache(time.Minute, time.Minute, time.Minute)
+	c.Put(Location{City: "Sydney", Country: "AU"}, Weather{TemperatureDegrees: 1})
+	_, freshness := c.Get(Location{City: "sydney", Country: "CA"})
+	assert.Equal(t, Missing, freshness)
+	_, freshness = c.Get(Location{City: "sydney", Country: "AU"})
+	assert.Equal(t, Fresh, freshness)
+}
@@ -0,0 +1,17 @@
+package weather
+
+import "time"
+
+// Metadata describes where a looked up value came from and how long it may be reused for.
+type Metadata struct {
+	// Provider is the name of the provider that served the value.
+	Provider string
+	// ObservedAt is the time the provider observed the value, when it reports it.
+	ObservedAt *time.Time
+	// Age is the time since the value was fetched from the provider, zero when it was fetched by the lookup.
+	Age time.Duration
+	// MaxAge is the time left until the value needs to be fetched again.
+	MaxAge time.Duration
+	// Stale values are served from the cache because every provider failed.
+	Stale bool
+}
@@ -0,0 +1,45 @@
+package weather
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Report_Provider_Of_Fetched_Weather(t *testing.T) {
+	observedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := provider(func(city string) (Weather, error) {
+		return Weather{TemperatureDegrees: 1, Provider: "provider-1", ObservedAt: &observedAt}, nil
+	})
+	service := NewWeatherService(newCacheAt(time.Now()), ServiceConfig{}, p)
+	_, metadata, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, Metadata{Provider: "provider-1", ObservedAt: &observedAt, MaxAge: time.Second * 3}, metadata)
+}
+
+func Test_Should_Report_Age_Of_Fresh_Cached_Weather(t *testing.T) {
+	now := time.Now()
+	cache := newCacheAt(now)
+	cache.Put(Location{City: "test"}, Weather{TemperatureDegrees: 1, Provider: "provider-1"})
+	cache.now = func() time.Time { return now.Add(time.Second * 2) }
+	service := NewWeatherService(cache, ServiceConfig{})
+	_, metadata, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, Metadata{Provider: "provider-1", Age: time.Second * 2, MaxAge: time.Second}, metadata)
+}
+
+func Test_Should_Report_Stale_Weather_When_All_Providers_Failed(t *testing.T) {
+	now := time.Now()
+	cache := newCacheAt(now)
+	cache.Put(Location{City: "test"}, Weather{TemperatureDegrees: 1, Provider: "provider-1"})
+	cache.now = func() time.Time { return now.Add(time.Second * 30) }
+	p := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	_, metadata, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, Metadata{Provider: "provider-1", Age: time.Second * 30, Stale: true}, metadata)
+}
@@ -0,0 +1,38 @@
+package weather
+
+import "math"
+
+// MaxPrecision is the maximum number of decimal places measurements can be rounded to.
+const MaxPrecision = 6
+
+// Round returns the weather with its measurements rounded to the number of decimal places.
+func (w Weather) Round(precision int) Weather {
+	rounded := w
+	rounded.WindSpeed = round(w.WindSpeed, precision)
+	rounded.TemperatureDegrees = round(w.TemperatureDegrees, precision)
+	rounded.WindGust = roundOptional(w.WindGust, precision)
+	rounded.WindDirection = roundOptional(w.WindDirection, precision)
+	rounded.Humidity = roundOptional(w.Humidity, precision)
+	rounded.Pressure = roundOptional(w.Pressure, precision)
+	rounded.Visibility = roundOptional(w.Visibility, precision)
+	rounded.CloudCover = roundOptional(w.CloudCover, precision)
+	return rounded
+}
+
+func round(value float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	rounded := math.Round(value*scale) / scale
+	if rounded == 0 {
+		// avoid negative zero, which is encoded as -0
+		return 0
+	}
+	return rounded
+}
+
+func roundOptional(value *float64, precision int) *float64 {
+	if value == nil {
+		return nil
+	}
+	rounded := round(*value, precision)
+	return &rounded
+}
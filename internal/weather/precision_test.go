@@ -0,0 +1,29 @@
+package weather
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Should_Round_Measurements_To_Integers_By_Default(t *testing.T) {
+	humidity := 80.6
+	w := Weather{WindSpeed: 7.2, TemperatureDegrees: 28.5, Humidity: &humidity}.Round(0)
+	assert.Equal(t, 7.0, w.WindSpeed)
+	assert.Equal(t, 29.0, w.TemperatureDegrees)
+	assert.Equal(t, 81.0, *w.Humidity)
+	assert.Equal(t, 80.6, humidity)
+}
+
+func Test_Should_Round_Measurements_To_Precision(t *testing.T) {
+	w := Weather{WindSpeed: 3.21869, TemperatureDegrees: -0.55555}.Round(2)
+	assert.Equal(t, 3.22, w.WindSpeed)
+	assert.Equal(t, -0.56, w.TemperatureDegrees)
+}
+
+func Test_Should_Encode_Rounded_Measurements_As_Integers(t *testing.T) {
+	w := Weather{WindSpeed: 20.2, TemperatureDegrees: -0.2}.Round(0)
+	data, err := json.Marshal(w)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"wind_speed":20,"temperature_degrees":0,`)
+}
@@ -1,10 +1,44 @@
 package weather
 
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrNotSupported is returned for lookups a provider has no capability for.
+var ErrNotSupported = errors.New("not supported by provider")
+
 type Provider interface {
-	Get(city string) (Weather, error)
+	Get(ctx context.Context, location Location) (Weather, error)
 }
 
+// Weather is the unified current weather. Optional measurements are nil when a provider lacks them.
+// Measurements keep the precision reported by the provider until they are rounded for a response.
 type Weather struct {
-	WindSpeed          int `json:"wind_speed"`
-	TemperatureDegrees int `json:"temperature_degrees"`
+	WindSpeed          float64 `json:"wind_speed"`
+	TemperatureDegrees float64 `json:"temperature_degrees"`
+	Units              Units   `json:"units"`
+	// WindGust is in the same unit as WindSpeed.
+	WindGust *float64 `json:"wind_gust,omitempty"`
+	// WindDirection is in degrees the wind blows from, clockwise from north.
+	WindDirection *float64 `json:"wind_direction,omitempty"`
+	// Humidity is relative humidity in percent.
+	Humidity *float64 `json:"humidity,omitempty"`
+	// Pressure is atmospheric pressure in hPa.
+	Pressure *float64 `json:"pressure,omitempty"`
+	// Visibility is in kilometres.
+	Visibility *float64 `json:"visibility,omitempty"`
+	// CloudCover is in percent.
+	CloudCover *float64   `json:"cloud_cover,omitempty"`
+	Condition  *Condition `json:"condition,omitempty"`
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+	// Provider is the name of the provider that served the weather.
+	Provider string `json:"provider,omitempty"`
+}
+
+// Condition describes the weather in words, with a provider specific code.
+type Condition struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
 }
@@ -0,0 +1,190 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// BomUrl is the base url of the Australian Bureau of Meteorology observation feeds.
+const BomUrl = "https://www.bom.gov.au"
+
+// BomStations maps Australian capital cities to the observation feeds of their stations,
+// in the format parsed by ParseBomStations.
+const BomStations = "sydney=IDN60901.94768,melbourne=IDV60901.95936,brisbane=IDQ60901.94576," +
+	"perth=IDW60901.94608,adelaide=IDS60901.94648,hobart=IDT60901.94970,darwin=IDD60901.94120," +
+	"canberra=IDN60903.94926"
+
+// bomTimeLayout is the layout of observation times in UTC, e.g. "20181022100000"
+const bomTimeLayout = "20060102150405"
+
+// bomStationPattern matches station feeds made of a product and a WMO station number, e.g. "IDN60901.94768"
+var bomStationPattern = regexp.MustCompile(`^ID[A-Z]\d{5}\.\d{5}$`)
+
+// bomCompassDirections are the degrees of the compass points BoM reports the wind direction in.
+var bomCompassDirections = map[string]float64{
+	"N": 0, "NNE": 22.5, "NE": 45, "ENE": 67.5, "E": 90, "ESE": 112.5, "SE": 135, "SSE": 157.5,
+	"S": 180, "SSW": 202.5, "SW": 225, "WSW": 247.5, "W": 270, "WNW": 292.5, "NW": 315, "NNW": 337.5,
+}
+
+// ParseBomStations parses a comma separated list of cities and their station feeds, e.g.
+// "sydney=IDN60901.94768,melbourne=IDV60901.95936", into a map of lower case cities to stations.
+func ParseBomStations(value string) (map[string]string, error) {
+	stations := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid bom station %q, expected city=station", entry)
+		}
+		station := strings.TrimSpace(parts[1])
+		if !bomStationPattern.MatchString(station) {
+			return nil, errors.Errorf("invalid bom station %q of %v, expected a feed like IDN60901.94768", station, parts[0])
+		}
+		stations[strings.ToLower(strings.TrimSpace(parts[0]))] = station
+	}
+	return stations, nil
+}
+
+// NewBomWeatherProvider creates a provider of the latest observations of the Bureau of Meteorology stations of
+// Australian cities at the base url, e.g. BomUrl. Cities missing from the stations are not supported.
+// The feeds reject requests without a browser like user agent.
+func NewBomWeatherProvider(client http.Client, baseUrl string, userAgent string, stations map[string]string) weather.Provider {
+	return &bomWeatherProvider{
+		client:    client,
+		baseUrl:   baseUrl,
+		userAgent: userAgent,
+		stations:  stations,
+	}
+}
+
+type bomWeatherProvider struct {
+	client    http.Client
+	baseUrl   string
+	userAgent string
+	stations  map[string]string
+}
+
+func (p *bomWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	station, ok := p.stations[strings.ToLower(location.City)]
+	if !ok || (location.Country != "" && location.Country != "AU") {
+		return weather.Weather{}, errors.Wrapf(weather.ErrNotSupported, "bom: %v has no station", location)
+	}
+	body, err := p.request(ctx, station)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "bom: failed to get %v weather", location)
+	}
+	defer body.Close()
+	return p.toWeather(body)
+}
+
+func (p *bomWeatherProvider) request(ctx context.Context, station string) (io.ReadCloser, error) {
+	product := station[:strings.Index(station, ".")]
+	urlString := p.baseUrl + "/fwo/" + product + "/" + station + ".json"
+	log.WithField("url", urlString).
+		WithField("provider", "bom").
+		Debug("sending http request")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	request.Header.Set("User-Agent", p.userAgent)
+	r, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(r); err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+type bomObservations struct {
+	Observations struct {
+		// Data are the observations of the last days, the latest first
+		Data []bomObservation `json:"data"`
+	} `json:"observations"`
+}
+
+// bomObservation has missing text values reported as "-", and missing numbers as null.
+type bomObservation struct {
+	Name        string   `json:"name"`
+	AifsTimeUtc string   `json:"aifstime_utc"`
+	AirTemp     *float64 `json:"air_temp"`
+	WindSpdKmh  *float64 `json:"wind_spd_kmh"`
+	GustKmh     *float64 `json:"gust_kmh"`
+	WindDir     string   `json:"wind_dir"`
+	RelHum      *float64 `json:"rel_hum"`
+	PressMsl    *float64 `json:"press_msl"`
+	VisKm       string   `json:"vis_km"`
+	CloudOktas  *float64 `json:"cloud_oktas"`
+	Cloud       string   `json:"cloud"`
+	Weather     string   `json:"weather"`
+}
+
+func (p *bomWeatherProvider) toWeather(data io.Reader) (weather.Weather, error) {
+	var response bomObservations
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Weather{}, errors.Wrap(err, "bom: failed to unmarshal json response")
+	}
+	if len(response.Observations.Data) == 0 {
+		return weather.Weather{}, errors.New("bom: feed has no observations")
+	}
+	observation := response.Observations.Data[0]
+	if observation.WindSpdKmh == nil {
+		return weather.Weather{}, errors.New("bom: failed to extract wind speed from response")
+	}
+	if observation.AirTemp == nil {
+		return weather.Weather{}, errors.New("bom: failed to extract temperature degrees from response")
+	}
+	w := weather.Weather{
+		WindSpeed:          *observation.WindSpdKmh,
+		TemperatureDegrees: *observation.AirTemp,
+		Units:              weather.CanonicalUnits,
+		WindGust:           observation.GustKmh,
+		Humidity:           observation.RelHum,
+		Pressure:           observation.PressMsl,
+		Condition:          bomCondition(observation),
+		Provider:           "bom",
+	}
+	if direction, ok := bomCompassDirections[observation.WindDir]; ok {
+		w.WindDirection = &direction
+	}
+	if visibility, err := strconv.ParseFloat(observation.VisKm, 64); err == nil {
+		w.Visibility = &visibility
+	}
+	if observation.CloudOktas != nil {
+		cloudCover := *observation.CloudOktas / 8 * 100
+		w.CloudCover = &cloudCover
+	}
+	if observedAt, err := time.Parse(bomTimeLayout, observation.AifsTimeUtc); err == nil {
+		w.ObservedAt = &observedAt
+	}
+	log.WithField("weather", w).
+		WithField("station", observation.Name).
+		WithField("provider", "bom").
+		Debug("got weather data")
+	return w, nil
+}
+
+// bomCondition describes the observed weather, e.g. "Rain", or the cloud when nothing else is observed.
+func bomCondition(observation bomObservation) *weather.Condition {
+	for _, text := range []string{observation.Weather, observation.Cloud} {
+		if text != "" && text != "-" {
+			return &weather.Condition{Code: text, Description: strings.ToLower(text)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+var bomTestStations = map[string]string{
+	"sydney":    "IDN60901.94768",
+	"melbourne": "IDV60901.95936",
+	"hobart":    "IDT60901.94970",
+}
+
+// newBomFixtureClient answers feed requests with the recorded feed of testdata/bom, or 404 when there is none.
+func newBomFixtureClient(requestAsserts ...func(*http.Request)) http.Client {
+	return http.Client{Transport: promhttp.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		for _, assertFunc := range requestAsserts {
+			assertFunc(req)
+		}
+		statusCode := http.StatusOK
+		data, err := ioutil.ReadFile(path.Join("testdata", "bom", path.Base(req.URL.Path)))
+		if err != nil {
+			statusCode = http.StatusNotFound
+		}
+		return &http.Response{
+			Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			StatusCode: statusCode,
+			Header:     make(http.Header),
+		}, nil
+	})}
+}
+
+func Test_Should_Build_Bom_Feed_Url_Of_City_Station(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "bom.test", req.URL.Host)
+		assert.Equal(t, "/fwo/IDN60901/IDN60901.94768.json", req.URL.Path)
+		assert.Equal(t, "weather-reporter/1.0 test@example.com", req.Header.Get("User-Agent"))
+	}
+	client := newBomFixtureClient(assertFunc)
+	provider := NewBomWeatherProvider(client, "http://bom.test", "weather-reporter/1.0 test@example.com", bomTestStations)
+	_, err := provider.Get(context.Background(), weather.Location{City: "Sydney", Country: "AU"})
+	assert.NoError(t, err)
+}
+
+func Test_Should_Return_Latest_Observation_Of_Bom_Feed(t *testing.T) {
+	provider := NewBomWeatherProvider(newBomFixtureClient(), BomUrl, "test", bomTestStations)
+	w, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	observedAt := time.Date(2018, 10, 22, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, weather.Weather{
+		WindSpeed:          19,
+		TemperatureDegrees: 21.4,
+		Units:              weather.CanonicalUnits,
+		WindGust:           float(31),
+		WindDirection:      float(135),
+		Humidity:           float(64),
+		Pressure:           float(1015.2),
+		Visibility:         float(10),
+		CloudCover:         float(50),
+		Condition:          &weather.Condition{Code: "Partly cloudy", Description: "partly cloudy"},
+		ObservedAt:         &observedAt,
+		Provider:           "bom",
+	}, w)
+}
+
+func Test_Should_Leave_Out_Missing_Values_Of_Bom_Observation(t *testing.T) {
+	provider := NewBomWeatherProvider(newBomFixtureClient(), BomUrl, "test", bomTestStations)
+	w, err := provider.Get(context.Background(), weather.Location{City: "melbourne"})
+	assert.NoError(t, err)
+	observedAt := time.Date(2018, 10, 22, 19, 0, 0, 0, time.UTC)
+	assert.Equal(t, weather.Weather{
+		WindSpeed:          0,
+		TemperatureDegrees: 10.3,
+		Units:              weather.CanonicalUnits,
+		Humidity:           float(91),
+		Condition:          &weather.Condition{Code: "Rain", Description: "rain"},
+		ObservedAt:         &observedAt,
+		Provider:           "bom",
+	}, w)
+}
+
+func Test_Should_Not_Support_Cities_Without_Bom_Station(t *testing.T) {
+	client := NewClientStub("", 0, nil, func(req *http.Request) {
+		t.Fatal("bom must not be called for cities without station")
+	})
+	provider := NewBomWeatherProvider(client, BomUrl, "test", bomTestStations)
+	for _, location := range []weather.Location{
+		{City: "Perth", Country: "AU"},
+		{City: "Sydney", Country: "CA"},
+		{Coordinates: sydney.Coordinates},
+	} {
+		_, err := provider.Get(context.Background(), location)
+		assert.Equal(t, weather.ErrNotSupported, errors.Cause(err), location.String())
+	}
+}
+
+func Test_Should_Return_Error_When_Bom_Feed_Is_Missing(t *testing.T) {
+	provider := NewBomWeatherProvider(newBomFixtureClient(), BomUrl, "test", bomTestStations)
+	_, err := provider.Get(context.Background(), weather.Location{City: "Hobart"})
+	assert.Contains(t, err.Error(), "request failed")
+}
+
+func Test_Should_Return_Error_From_Bom_Http_Client(t *testing.T) {
+	msg := "test error message"
+	provider := NewBomWeatherProvider(NewClientStub("", 0, errors.New(msg)), BomUrl, "test", bomTestStations)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), msg)
+}
+
+func Test_Should_Return_Error_When_Bom_Response_Is_Not_Valid_Json(t *testing.T) {
+	provider := NewBomWeatherProvider(NewClientStub("TEST", 200, nil), BomUrl, "test", bomTestStations)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to unmarshal json")
+}
+
+func Test_Should_Return_Error_When_Bom_Feed_Has_No_Observations(t *testing.T) {
+	provider := NewBomWeatherProvider(NewClientStub(`{"observations":{"data":[]}}`, 200, nil), BomUrl, "test", bomTestStations)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "feed has no observations")
+}
+
+func Test_Should_Return_Error_When_Bom_Observation_Has_No_Temperature(t *testing.T) {
+	client := NewClientStub(`{"observations":{"data":[{"wind_spd_kmh":1,"air_temp":null}]}}`, 200, nil)
+	provider := NewBomWeatherProvider(client, BomUrl, "test", bomTestStations)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to extract temperature")
+}
+
+func Test_Should_Parse_Bom_Stations(t *testing.T) {
+	stations, err := ParseBomStations(" Sydney=IDN60901.94768, melbourne = IDV60901.95936,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"sydney": "IDN60901.94768", "melbourne": "IDV60901.95936"}, stations)
+
+	stations, err = ParseBomStations(BomStations)
+	assert.NoError(t, err)
+	assert.Equal(t, "IDN60901.94768", stations["sydney"])
+}
+
+func Test_Should_Return_Error_When_Bom_Stations_Are_Invalid(t *testing.T) {
+	for _, value := range []string{"sydney", "=IDN60901.94768", "sydney=94768", "sydney=IDN60901.94768/../x"} {
+		_, err := ParseBomStations(value)
+		assert.Error(t, err, value)
+	}
+}
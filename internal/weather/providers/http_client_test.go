@@ -21,3 +21,7 @@ func NewClientStub(data string, statusCode int, err error, requestAsserts ...fun
 	}
 	return http.Client{Transport: promhttp.RoundTripperFunc(handler)}
 }
+
+func float(value float64) *float64 {
+	return &value
+}
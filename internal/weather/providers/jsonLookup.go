@@ -0,0 +1,52 @@
+package providers
+
+import (
+	"github.com/oliveagle/jsonpath"
+	"strconv"
+)
+
+// lookupOptionalFloat returns the number at the json path, which may also be encoded as a string,
+// or nil if it is missing or not a number.
+func lookupOptionalFloat(jsonData interface{}, path string) *float64 {
+	value, err := jsonpath.JsonPathLookup(jsonData, path)
+	if err != nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil
+		}
+		return &f
+	default:
+		return nil
+	}
+}
+
+// lookupOptionalString returns the string at the json path or an empty string if it is missing.
+func lookupOptionalString(jsonData interface{}, path string) string {
+	value, err := jsonpath.JsonPathLookup(jsonData, path)
+	if err != nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// scaleOptionalFloat multiplies the value by the factor, keeping a missing value missing.
+func scaleOptionalFloat(value *float64, factor float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	scaled := *value * factor
+	return &scaled
+}
@@ -0,0 +1,269 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// MetNorwayUrl is the base url of the MET Norway weather API.
+const MetNorwayUrl = "https://api.met.no/weatherapi"
+
+// metNorwayRetention is how long responses are kept after they expire, to be revalidated with If-Modified-Since.
+const metNorwayRetention = time.Hour
+
+// metNorwaySymbols describes the weather symbols of MET Norway, without their _day, _night and _polartwilight variants.
+var metNorwaySymbols = map[string]string{
+	"clearsky":                   "clear sky",
+	"fair":                       "fair",
+	"partlycloudy":               "partly cloudy",
+	"cloudy":                     "cloudy",
+	"fog":                        "fog",
+	"lightrainshowers":           "light rain showers",
+	"rainshowers":                "rain showers",
+	"heavyrainshowers":           "heavy rain showers",
+	"lightrainshowersandthunder": "light rain showers and thunder",
+	"rainshowersandthunder":      "rain showers and thunder",
+	"heavyrainshowersandthunder": "heavy rain showers and thunder",
+	"lightsleetshowers":          "light sleet showers",
+	"sleetshowers":               "sleet showers",
+	"heavysleetshowers":          "heavy sleet showers",
+	// MET Norway misspells light sleet and snow showers with thunder
+	"lightssleetshowersandthunder": "light sleet showers and thunder",
+	"sleetshowersandthunder":       "sleet showers and thunder",
+	"heavysleetshowersandthunder":  "heavy sleet showers and thunder",
+	"lightsnowshowers":             "light snow showers",
+	"snowshowers":                  "snow showers",
+	"heavysnowshowers":             "heavy snow showers",
+	"lightssnowshowersandthunder":  "light snow showers and thunder",
+	"snowshowersandthunder":        "snow showers and thunder",
+	"heavysnowshowersandthunder":   "heavy snow showers and thunder",
+	"lightrain":                    "light rain",
+	"rain":                         "rain",
+	"heavyrain":                    "heavy rain",
+	"lightrainandthunder":          "light rain and thunder",
+	"rainandthunder":               "rain and thunder",
+	"heavyrainandthunder":          "heavy rain and thunder",
+	"lightsleet":                   "light sleet",
+	"sleet":                        "sleet",
+	"heavysleet":                   "heavy sleet",
+	"lightsleetandthunder":         "light sleet and thunder",
+	"sleetandthunder":              "sleet and thunder",
+	"heavysleetandthunder":         "heavy sleet and thunder",
+	"lightsnow":                    "light snow",
+	"snow":                         "snow",
+	"heavysnow":                    "heavy snow",
+	"lightsnowandthunder":          "light snow and thunder",
+	"snowandthunder":               "snow and thunder",
+	"heavysnowandthunder":          "heavy snow and thunder",
+}
+
+// NewMetNorwayWeatherProvider creates a provider of the MET Norway Locationforecast API at the base url,
+// e.g. MetNorwayUrl. Their terms of service require the user agent to identify the application along with
+// a contact. MET Norway looks locations up by coordinates only.
+//
+// Responses are kept per coordinates until they expire, as told by their Expires header, and are then
+// revalidated with If-Modified-Since.
+func NewMetNorwayWeatherProvider(client http.Client, baseUrl string, userAgent string) weather.Provider {
+	return &metNorwayWeatherProvider{
+		client:    client,
+		baseUrl:   baseUrl,
+		userAgent: userAgent,
+		now:       time.Now,
+		responses: cache.New(cache.NoExpiration, metNorwayRetention),
+	}
+}
+
+type metNorwayWeatherProvider struct {
+	client    http.Client
+	baseUrl   string
+	userAgent string
+	now       func() time.Time
+	// responses are keyed by the coordinates they were requested for
+	responses *cache.Cache
+}
+
+type metNorwayResponse struct {
+	forecast     metNorwayForecast
+	lastModified string
+	expires      time.Time
+}
+
+func (p *metNorwayWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	if location.Coordinates == nil {
+		return weather.Weather{}, errors.Wrapf(weather.ErrNotSupported, "metNorway: %v has no coordinates", location)
+	}
+	forecast, err := p.fetch(ctx, *location.Coordinates)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "metNorway: failed to get %v weather", location)
+	}
+	return p.toWeather(forecast)
+}
+
+// fetch returns the forecast of the coordinates, only calling MET Norway once the kept response has expired.
+func (p *metNorwayWeatherProvider) fetch(ctx context.Context, coordinates weather.Coordinates) (metNorwayForecast, error) {
+	// the terms of service ask for at most 4 decimals, more only defeat their caches
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(coordinates.Latitude, 'f', 4, 64))
+	params.Set("lon", strconv.FormatFloat(coordinates.Longitude, 'f', 4, 64))
+	key := params.Encode()
+
+	var cached metNorwayResponse
+	value, ok := p.responses.Get(key)
+	if ok {
+		cached = value.(metNorwayResponse)
+	}
+	if ok && p.now().Before(cached.expires) {
+		return cached.forecast, nil
+	}
+
+	urlString := p.baseUrl + "/locationforecast/2.0/compact?" + key
+	log.WithField("url", urlString).
+		WithField("provider", "metNorway").
+		Debug("sending http request")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return metNorwayForecast{}, errors.Wrap(err, "failed to build request")
+	}
+	request.Header.Set("User-Agent", p.userAgent)
+	if ok && cached.lastModified != "" {
+		request.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+	r, err := p.client.Do(request)
+	if err != nil {
+		return metNorwayForecast{}, err
+	}
+	if r.StatusCode == http.StatusNotModified && ok {
+		r.Body.Close()
+		cached.expires = p.expiresOf(r)
+		p.store(key, cached)
+		return cached.forecast, nil
+	}
+	if err := checkResponse(r); err != nil {
+		return metNorwayForecast{}, err
+	}
+	defer r.Body.Close()
+	var forecast metNorwayForecast
+	if err := json.NewDecoder(r.Body).Decode(&forecast); err != nil {
+		return metNorwayForecast{}, errors.Wrap(err, "failed to unmarshal json response")
+	}
+	p.store(key, metNorwayResponse{
+		forecast:     forecast,
+		lastModified: r.Header.Get("Last-Modified"),
+		expires:      p.expiresOf(r),
+	})
+	return forecast, nil
+}
+
+// expiresOf returns the time the response expires at, which is now when it has no valid Expires header.
+func (p *metNorwayWeatherProvider) expiresOf(r *http.Response) time.Time {
+	expires, err := http.ParseTime(r.Header.Get("Expires"))
+	if err != nil {
+		return p.now()
+	}
+	return expires
+}
+
+// store keeps the response of the coordinates until it has been expired for metNorwayRetention.
+func (p *metNorwayWeatherProvider) store(key string, response metNorwayResponse) {
+	ttl := response.expires.Sub(p.now())
+	if ttl < 0 {
+		ttl = 0
+	}
+	p.responses.Set(key, response, ttl+metNorwayRetention)
+}
+
+type metNorwayForecast struct {
+	Properties struct {
+		Timeseries []metNorwayTimestep `json:"timeseries"`
+	} `json:"properties"`
+}
+
+type metNorwayTimestep struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Instant struct {
+			// Details have wind speeds in m/s
+			Details struct {
+				AirTemperature        *float64 `json:"air_temperature"`
+				WindSpeed             *float64 `json:"wind_speed"`
+				WindSpeedOfGust       *float64 `json:"wind_speed_of_gust"`
+				WindFromDirection     *float64 `json:"wind_from_direction"`
+				RelativeHumidity      *float64 `json:"relative_humidity"`
+				AirPressureAtSeaLevel *float64 `json:"air_pressure_at_sea_level"`
+				CloudAreaFraction     *float64 `json:"cloud_area_fraction"`
+			} `json:"details"`
+		} `json:"instant"`
+		NextOneHours *struct {
+			Summary struct {
+				SymbolCode string `json:"symbol_code"`
+			} `json:"summary"`
+		} `json:"next_1_hours"`
+	} `json:"data"`
+}
+
+func (p *metNorwayWeatherProvider) toWeather(forecast metNorwayForecast) (weather.Weather, error) {
+	timeseries := forecast.Properties.Timeseries
+	if len(timeseries) == 0 {
+		return weather.Weather{}, errors.New("metNorway: forecast has no timeseries")
+	}
+	// the current weather is the latest step that has begun, the forecast starts at the current hour
+	current := timeseries[0]
+	now := p.now()
+	for _, step := range timeseries[1:] {
+		if step.Time.After(now) {
+			break
+		}
+		current = step
+	}
+	details := current.Data.Instant.Details
+	if details.WindSpeed == nil {
+		return weather.Weather{}, errors.New("metNorway: failed to extract wind speed from response")
+	}
+	if details.AirTemperature == nil {
+		return weather.Weather{}, errors.New("metNorway: failed to extract temperature degrees from response")
+	}
+	// the weather is forecast rather than observed, so it has no observation time
+	w := weather.Weather{
+		WindSpeed:          weather.ToWindSpeed(*details.WindSpeed, weather.MetresPerSecond),
+		TemperatureDegrees: *details.AirTemperature,
+		Units:              weather.CanonicalUnits,
+		WindGust:           weather.ToOptionalWindSpeed(details.WindSpeedOfGust, weather.MetresPerSecond),
+		WindDirection:      details.WindFromDirection,
+		Humidity:           details.RelativeHumidity,
+		Pressure:           details.AirPressureAtSeaLevel,
+		CloudCover:         details.CloudAreaFraction,
+		Provider:           "metNorway",
+	}
+	if current.Data.NextOneHours != nil {
+		w.Condition = metNorwayCondition(current.Data.NextOneHours.Summary.SymbolCode)
+	}
+	log.WithField("weather", w).
+		WithField("provider", "metNorway").
+		Debug("got weather data")
+	return w, nil
+}
+
+// metNorwayCondition describes a symbol code like "partlycloudy_day", whatever the time of the day.
+func metNorwayCondition(symbolCode string) *weather.Condition {
+	if symbolCode == "" {
+		return nil
+	}
+	symbol := symbolCode
+	if i := strings.Index(symbol, "_"); i >= 0 {
+		symbol = symbol[:i]
+	}
+	description, ok := metNorwaySymbols[symbol]
+	if !ok {
+		description = symbol
+	}
+	return &weather.Condition{Code: symbolCode, Description: description}
+}
@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+const metNorwayResponseBody = `{"properties":{"timeseries":[
+	{"time":"2018-10-22T10:00:00Z","data":{"instant":{"details":{"air_pressure_at_sea_level":1015.2,
+		"air_temperature":21.4,"cloud_area_fraction":40,"relative_humidity":64,"wind_from_direction":130,
+		"wind_speed":5}},"next_1_hours":{"summary":{"symbol_code":"partlycloudy_day"}}}},
+	{"time":"2018-10-22T11:00:00Z","data":{"instant":{"details":{"air_temperature":22.1,"wind_speed":6}}}}
+]}}`
+
+var metNorwayNow = time.Date(2018, 10, 22, 10, 30, 0, 0, time.UTC)
+
+// metNorwayServerStub answers every request with the response, counting requests.
+type metNorwayServerStub struct {
+	requests     []*http.Request
+	statusCode   int
+	lastModified string
+	expires      time.Time
+}
+
+func (s *metNorwayServerStub) client() http.Client {
+	return http.Client{Transport: promhttp.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		s.requests = append(s.requests, req)
+		header := make(http.Header)
+		header.Set("Last-Modified", s.lastModified)
+		header.Set("Expires", s.expires.Format(http.TimeFormat))
+		body := ""
+		if s.statusCode == http.StatusOK {
+			body = metNorwayResponseBody
+		}
+		return &http.Response{
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			StatusCode: s.statusCode,
+			Header:     header,
+		}, nil
+	})}
+}
+
+func newMetNorwayProvider(client http.Client, now *time.Time) weather.Provider {
+	provider := NewMetNorwayWeatherProvider(client, "https://met.test/weatherapi", "weather-reporter test@example.com")
+	provider.(*metNorwayWeatherProvider).now = func() time.Time { return *now }
+	return provider
+}
+
+func Test_Should_Build_Met_Norway_Api_Url_With_User_Agent(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "met.test", req.URL.Host)
+		assert.Equal(t, "/weatherapi/locationforecast/2.0/compact", req.URL.Path)
+		assert.Equal(t, "-33.8688", req.URL.Query().Get("lat"))
+		assert.Equal(t, "151.2093", req.URL.Query().Get("lon"))
+		assert.Equal(t, "weather-reporter test@example.com", req.Header.Get("User-Agent"))
+		assert.Empty(t, req.Header.Get("If-Modified-Since"))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	_, _ = newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+}
+
+func Test_Should_Round_Met_Norway_Coordinates_To_Four_Decimals(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "-33.8688", req.URL.Query().Get("lat"))
+		assert.Equal(t, "151.2093", req.URL.Query().Get("lon"))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	location := weather.Location{Coordinates: &weather.Coordinates{Latitude: -33.868812, Longitude: 151.209291}}
+	_, _ = newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), location)
+}
+
+func Test_Should_Not_Support_Met_Norway_Lookup_Without_Coordinates(t *testing.T) {
+	client := NewClientStub("", 0, nil, func(req *http.Request) {
+		t.Fatal("met norway must not be called without coordinates")
+	})
+	_, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), weather.Location{City: "Sydney"})
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Return_Error_From_Met_Norway_Http_Client(t *testing.T) {
+	msg := "test error message"
+	client := NewClientStub("", 0, errors.New(msg))
+	_, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), msg)
+}
+
+func Test_Should_Return_Error_When_Met_Norway_Response_Status_Is_Not_OK(t *testing.T) {
+	client := NewClientStub("", 500, nil)
+	_, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "request failed")
+}
+
+func Test_Should_Return_Error_When_Met_Norway_Response_Is_Not_Valid_Json(t *testing.T) {
+	client := NewClientStub("TEST", 200, nil)
+	_, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to unmarshal json")
+}
+
+func Test_Should_Return_Error_When_Met_Norway_Response_Has_No_Timeseries(t *testing.T) {
+	client := NewClientStub(`{"properties":{"timeseries":[]}}`, 200, nil)
+	_, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "forecast has no timeseries")
+}
+
+func Test_Should_Return_Current_Weather_From_Met_Norway_Response(t *testing.T) {
+	client := NewClientStub(metNorwayResponseBody, 200, nil)
+	w, err := newMetNorwayProvider(client, &metNorwayNow).Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Equal(t, weather.Weather{
+		WindSpeed:          18,
+		TemperatureDegrees: 21.4,
+		Units:              weather.CanonicalUnits,
+		WindDirection:      float(130),
+		Humidity:           float(64),
+		Pressure:           float(1015.2),
+		CloudCover:         float(40),
+		Condition:          &weather.Condition{Code: "partlycloudy_day", Description: "partly cloudy"},
+		Provider:           "metNorway",
+	}, w)
+}
+
+func Test_Should_Return_Latest_Begun_Step_Of_Met_Norway_Timeseries(t *testing.T) {
+	client := NewClientStub(metNorwayResponseBody, 200, nil)
+	now := time.Date(2018, 10, 22, 11, 5, 0, 0, time.UTC)
+	w, err := newMetNorwayProvider(client, &now).Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Equal(t, 22.1, w.TemperatureDegrees)
+	assert.Nil(t, w.Condition)
+}
+
+func Test_Should_Not_Request_Met_Norway_Before_Response_Expires(t *testing.T) {
+	server := &metNorwayServerStub{statusCode: 200, expires: metNorwayNow.Add(time.Minute)}
+	now := metNorwayNow
+	provider := newMetNorwayProvider(server.client(), &now)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	now = now.Add(59 * time.Second)
+	w, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Equal(t, 21.4, w.TemperatureDegrees)
+	assert.Len(t, server.requests, 1)
+}
+
+func Test_Should_Request_Met_Norway_Per_Coordinates(t *testing.T) {
+	server := &metNorwayServerStub{statusCode: 200, expires: metNorwayNow.Add(time.Minute)}
+	provider := newMetNorwayProvider(server.client(), &metNorwayNow)
+	_, _ = provider.Get(context.Background(), sydney)
+	_, _ = provider.Get(context.Background(), weather.Location{Coordinates: &weather.Coordinates{Latitude: 59.91, Longitude: 10.75}})
+	assert.Len(t, server.requests, 2)
+}
+
+func Test_Should_Revalidate_Expired_Met_Norway_Response_With_If_Modified_Since(t *testing.T) {
+	lastModified := "Mon, 22 Oct 2018 10:05:00 GMT"
+	server := &metNorwayServerStub{statusCode: 200, lastModified: lastModified, expires: metNorwayNow.Add(time.Minute)}
+	now := metNorwayNow
+	provider := newMetNorwayProvider(server.client(), &now)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	server.statusCode = http.StatusNotModified
+	server.expires = now.Add(time.Minute)
+	w, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Equal(t, 21.4, w.TemperatureDegrees)
+	assert.Len(t, server.requests, 2)
+	assert.Equal(t, lastModified, server.requests[1].Header.Get("If-Modified-Since"))
+
+	// the revalidated response is kept until its new expiry
+	now = now.Add(30 * time.Second)
+	_, err = provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Len(t, server.requests, 2)
+}
+
+func Test_Should_Request_Met_Norway_Again_When_Response_Has_No_Expires(t *testing.T) {
+	server := &metNorwayServerStub{statusCode: 200}
+	provider := newMetNorwayProvider(server.client(), &metNorwayNow)
+	_, _ = provider.Get(context.Background(), sydney)
+	_, _ = provider.Get(context.Background(), sydney)
+	assert.Len(t, server.requests, 2)
+}
+
+func Test_Should_Describe_Met_Norway_Symbols_Whatever_The_Time_Of_Day(t *testing.T) {
+	assert.Equal(t, &weather.Condition{Code: "lightrain", Description: "light rain"}, metNorwayCondition("lightrain"))
+	assert.Equal(t, &weather.Condition{Code: "clearsky_polartwilight", Description: "clear sky"},
+		metNorwayCondition("clearsky_polartwilight"))
+	assert.Equal(t, &weather.Condition{Code: "unknown_night", Description: "unknown"}, metNorwayCondition("unknown_night"))
+	assert.Nil(t, metNorwayCondition(""))
+}
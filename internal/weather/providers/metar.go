@@ -0,0 +1,243 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"weather-reporter/internal/metar"
+	"weather-reporter/internal/weather"
+)
+
+// AviationWeatherUrl is the base url of the Aviation Weather Center data API, which serves METAR reports.
+const AviationWeatherUrl = "https://aviationweather.gov"
+
+// metarAirportTTL is how long the nearest airport of a point is kept.
+const metarAirportTTL = time.Hour * 24
+
+// metarSearchDegrees is how far from a point in latitude and longitude airports are looked for.
+const metarSearchDegrees = 0.5
+
+// metarCloudCovers are the cloud covers in percent of the cloud layers, the sky is covered by its densest layer.
+var metarCloudCovers = map[string]float64{
+	"FEW": 25,
+	"SCT": 50,
+	"BKN": 75,
+	"OVC": 100,
+	"VV":  100,
+}
+
+var metarSpeedUnits = map[string]weather.SpeedUnit{
+	metar.Knots:             weather.Knots,
+	metar.MetresPerSecond:   weather.MetresPerSecond,
+	metar.KilometresPerHour: weather.KilometresPerHour,
+}
+
+// NewMetarWeatherProvider creates a provider of the METAR reports of airports, served by the Aviation Weather Center
+// at the base url, e.g. AviationWeatherUrl. Locations are looked up by coordinates only: the nearest airport
+// issuing METAR reports, e.g. YSSY for Sydney, is kept for metarAirportTTL and its latest report is decoded.
+func NewMetarWeatherProvider(client http.Client, baseUrl string) weather.Provider {
+	return &metarWeatherProvider{
+		client:   client,
+		baseUrl:  baseUrl,
+		now:      time.Now,
+		airports: cache.New(metarAirportTTL, metarAirportTTL),
+	}
+}
+
+type metarWeatherProvider struct {
+	client  http.Client
+	baseUrl string
+	now     func() time.Time
+	// airports maps points to their nearest airport, or to "" when there is none
+	airports *cache.Cache
+}
+
+func (p *metarWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	if location.Coordinates == nil {
+		return weather.Weather{}, errors.Wrapf(weather.ErrNotSupported, "metar: %v has no coordinates", location)
+	}
+	airport, err := p.airport(ctx, *location.Coordinates)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "metar: failed to get %v airport", location)
+	}
+	params := url.Values{}
+	params.Set("ids", airport)
+	params.Set("format", "raw")
+	body, err := p.request(ctx, "/api/data/metar", params)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "metar: failed to get %v weather", location)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "metar: failed to read %v report", airport)
+	}
+	// the latest report is the first line
+	text := strings.TrimSpace(strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)[0])
+	if text == "" {
+		return weather.Weather{}, errors.Errorf("metar: %v has no recent report", airport)
+	}
+	report, err := metar.Parse(text)
+	if err != nil {
+		return weather.Weather{}, err
+	}
+	return p.toWeather(report)
+}
+
+// airport returns the ICAO code of the airport issuing METAR reports nearest to the coordinates.
+func (p *metarWeatherProvider) airport(ctx context.Context, coordinates weather.Coordinates) (string, error) {
+	point := fmt.Sprintf("%.4f,%.4f", coordinates.Latitude, coordinates.Longitude)
+	if airport, ok := p.airports.Get(point); ok {
+		if airport == "" {
+			return "", errors.Wrapf(weather.ErrNotSupported, "%v has no airport", point)
+		}
+		return airport.(string), nil
+	}
+	params := url.Values{}
+	params.Set("bbox", fmt.Sprintf("%.4f,%.4f,%.4f,%.4f",
+		coordinates.Latitude-metarSearchDegrees, coordinates.Longitude-metarSearchDegrees,
+		coordinates.Latitude+metarSearchDegrees, coordinates.Longitude+metarSearchDegrees))
+	params.Set("format", "json")
+	body, err := p.request(ctx, "/api/data/stationinfo", params)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get stations")
+	}
+	defer body.Close()
+	var stations []metarStation
+	// no content means no stations
+	if err := json.NewDecoder(body).Decode(&stations); err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "failed to unmarshal stations json response")
+	}
+	airport := ""
+	nearest := math.Inf(1)
+	for _, station := range stations {
+		if !station.reportsMetar() {
+			continue
+		}
+		// distances are compared in degrees, scaling longitudes to their length at the latitude
+		dLat := station.Lat - coordinates.Latitude
+		dLon := (station.Lon - coordinates.Longitude) * math.Cos(coordinates.Latitude*math.Pi/180)
+		if distance := dLat*dLat + dLon*dLon; distance < nearest {
+			airport = station.IcaoId
+			nearest = distance
+		}
+	}
+	log.WithField("point", point).
+		WithField("airport", airport).
+		WithField("provider", "metar").
+		Debug("resolved point to airport")
+	p.airports.SetDefault(point, airport)
+	if airport == "" {
+		return "", errors.Wrapf(weather.ErrNotSupported, "%v has no airport", point)
+	}
+	return airport, nil
+}
+
+func (p *metarWeatherProvider) request(ctx context.Context, path string, params url.Values) (io.ReadCloser, error) {
+	urlString := p.baseUrl + path + "?" + params.Encode()
+	log.WithField("url", urlString).
+		WithField("provider", "metar").
+		Debug("sending http request")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	r, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode == http.StatusNoContent {
+		// nothing matched the query
+		r.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if err := checkResponse(r); err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+type metarStation struct {
+	IcaoId   string   `json:"icaoId"`
+	Lat      float64  `json:"lat"`
+	Lon      float64  `json:"lon"`
+	SiteType []string `json:"siteType"`
+}
+
+func (s metarStation) reportsMetar() bool {
+	if s.IcaoId == "" {
+		return false
+	}
+	for _, siteType := range s.SiteType {
+		if siteType == "METAR" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *metarWeatherProvider) toWeather(report metar.Report) (weather.Weather, error) {
+	if report.Wind == nil {
+		return weather.Weather{}, errors.Errorf("metar: failed to extract wind speed from %v report", report.Station)
+	}
+	if report.Temperature == nil {
+		return weather.Weather{}, errors.Errorf("metar: failed to extract temperature degrees from %v report", report.Station)
+	}
+	unit := metarSpeedUnits[report.Wind.Unit]
+	observedAt := report.ObservedAt(p.now())
+	w := weather.Weather{
+		WindSpeed:          weather.ToWindSpeed(report.Wind.Speed, unit),
+		TemperatureDegrees: *report.Temperature,
+		Units:              weather.CanonicalUnits,
+		WindGust:           weather.ToOptionalWindSpeed(report.Wind.Gust, unit),
+		WindDirection:      report.Wind.Direction,
+		Pressure:           report.Pressure,
+		ObservedAt:         &observedAt,
+		Provider:           "metar",
+	}
+	if report.DewPoint != nil {
+		humidity := relativeHumidity(*report.Temperature, *report.DewPoint)
+		w.Humidity = &humidity
+	}
+	if report.Visibility != nil {
+		visibility := *report.Visibility / 1000
+		w.Visibility = &visibility
+	}
+	if report.Clouds != nil {
+		cloudCover := 0.0
+		for _, cloud := range report.Clouds {
+			cloudCover = math.Max(cloudCover, metarCloudCovers[cloud.Cover])
+		}
+		w.CloudCover = &cloudCover
+	}
+	if len(report.Weather) > 0 {
+		descriptions := make([]string, len(report.Weather))
+		for i, group := range report.Weather {
+			descriptions[i] = metar.Describe(group)
+		}
+		w.Condition = &weather.Condition{Code: strings.Join(report.Weather, " "), Description: strings.Join(descriptions, ", ")}
+	}
+	log.WithField("weather", w).
+		WithField("airport", report.Station).
+		WithField("provider", "metar").
+		Debug("got weather data")
+	return w, nil
+}
+
+// relativeHumidity derives the relative humidity in percent from the temperature and the dew point in Celsius
+// with the Magnus formula.
+func relativeHumidity(temperature float64, dewPoint float64) float64 {
+	magnus := func(degrees float64) float64 {
+		return math.Exp(17.625 * degrees / (243.04 + degrees))
+	}
+	return math.Min(100, 100*magnus(dewPoint)/magnus(temperature))
+}
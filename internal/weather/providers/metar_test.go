@@ -0,0 +1,160 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+const (
+	metarStationsBody = `[
+		{"icaoId":"YSBK","lat":-33.924,"lon":150.988,"siteType":["METAR","TAF"]},
+		{"icaoId":"YSSY","lat":-33.946,"lon":151.177,"siteType":["METAR","TAF"]},
+		{"icaoId":"YSRN","lat":-33.87,"lon":151.21,"siteType":[]}
+	]`
+	metarReportBody = "METAR YSSY 221000Z 13010G18KT 100V160 9999 -SHRA FEW020 SCT035 21/14 Q1015\n" +
+		"METAR YSSY 220930Z 13010KT 9999 FEW020 21/14 Q1015\n"
+)
+
+// metarServerStub answers requests by their path, counting requests of every path.
+type metarServerStub struct {
+	responses map[string]string
+	requests  map[string][]*http.Request
+}
+
+func newMetarServerStub() *metarServerStub {
+	return &metarServerStub{
+		responses: map[string]string{
+			"/api/data/stationinfo": metarStationsBody,
+			"/api/data/metar":       metarReportBody,
+		},
+		requests: make(map[string][]*http.Request),
+	}
+}
+
+func (s *metarServerStub) client() http.Client {
+	return http.Client{Transport: promhttp.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		s.requests[req.URL.Path] = append(s.requests[req.URL.Path], req)
+		body := s.responses[req.URL.Path]
+		statusCode := http.StatusOK
+		if body == "" {
+			statusCode = http.StatusNoContent
+		}
+		return &http.Response{
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+			StatusCode: statusCode,
+			Header:     make(http.Header),
+		}, nil
+	})}
+}
+
+func newMetarProvider(client http.Client) weather.Provider {
+	provider := NewMetarWeatherProvider(client, "https://metar.test")
+	provider.(*metarWeatherProvider).now = func() time.Time { return time.Date(2018, 10, 22, 10, 5, 0, 0, time.UTC) }
+	return provider
+}
+
+func Test_Should_Request_Latest_Report_Of_Nearest_Airport(t *testing.T) {
+	server := newMetarServerStub()
+	_, err := newMetarProvider(server.client()).Get(context.Background(), sydney)
+	assert.NoError(t, err)
+
+	stationsQuery := server.requests["/api/data/stationinfo"][0].URL.Query()
+	assert.Equal(t, "-34.3688,150.7093,-33.3688,151.7093", stationsQuery.Get("bbox"))
+	assert.Equal(t, "json", stationsQuery.Get("format"))
+	metarQuery := server.requests["/api/data/metar"][0].URL.Query()
+	assert.Equal(t, "YSSY", metarQuery.Get("ids"))
+	assert.Equal(t, "raw", metarQuery.Get("format"))
+}
+
+func Test_Should_Return_Weather_From_Metar_Report(t *testing.T) {
+	server := newMetarServerStub()
+	w, err := newMetarProvider(server.client()).Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	observedAt := time.Date(2018, 10, 22, 10, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 18.52, w.WindSpeed, 1e-9)
+	assert.InDelta(t, 33.336, *w.WindGust, 1e-9)
+	assert.InDelta(t, 64.3, *w.Humidity, 0.1)
+	w.WindSpeed, w.WindGust, w.Humidity = 0, nil, nil
+	assert.Equal(t, weather.Weather{
+		TemperatureDegrees: 21,
+		Units:              weather.CanonicalUnits,
+		WindDirection:      float(130),
+		Pressure:           float(1015),
+		Visibility:         float(10),
+		CloudCover:         float(50),
+		Condition:          &weather.Condition{Code: "-SHRA", Description: "light rain showers"},
+		ObservedAt:         &observedAt,
+		Provider:           "metar",
+	}, w)
+}
+
+func Test_Should_Keep_Nearest_Airport_Of_Point(t *testing.T) {
+	server := newMetarServerStub()
+	provider := newMetarProvider(server.client())
+	_, _ = provider.Get(context.Background(), sydney)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	assert.Len(t, server.requests["/api/data/stationinfo"], 1)
+	assert.Len(t, server.requests["/api/data/metar"], 2)
+}
+
+func Test_Should_Not_Support_Points_Without_Airport(t *testing.T) {
+	server := newMetarServerStub()
+	server.responses["/api/data/stationinfo"] = ""
+	provider := newMetarProvider(server.client())
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+	_, err = provider.Get(context.Background(), sydney)
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+	assert.Len(t, server.requests["/api/data/stationinfo"], 1)
+	assert.Empty(t, server.requests["/api/data/metar"])
+}
+
+func Test_Should_Not_Support_Metar_Lookup_Without_Coordinates(t *testing.T) {
+	client := NewClientStub("", 0, nil, func(req *http.Request) {
+		t.Fatal("metar must not be requested without coordinates")
+	})
+	_, err := newMetarProvider(client).Get(context.Background(), weather.Location{City: "Sydney"})
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Return_Error_When_Airport_Has_No_Recent_Report(t *testing.T) {
+	server := newMetarServerStub()
+	server.responses["/api/data/metar"] = ""
+	_, err := newMetarProvider(server.client()).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "YSSY has no recent report")
+}
+
+func Test_Should_Return_Error_When_Metar_Report_Is_Invalid(t *testing.T) {
+	server := newMetarServerStub()
+	server.responses["/api/data/metar"] = "METAR YSSY 2210Z"
+	_, err := newMetarProvider(server.client()).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "invalid time")
+}
+
+func Test_Should_Return_Error_When_Metar_Report_Has_No_Temperature(t *testing.T) {
+	server := newMetarServerStub()
+	server.responses["/api/data/metar"] = "METAR YSSY 221000Z 13010KT 9999 Q1015"
+	_, err := newMetarProvider(server.client()).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to extract temperature")
+}
+
+func Test_Should_Return_Error_From_Metar_Http_Client(t *testing.T) {
+	msg := "test error message"
+	_, err := newMetarProvider(NewClientStub("", 0, errors.New(msg))).Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), msg)
+}
+
+func Test_Should_Derive_Relative_Humidity_From_Dew_Point(t *testing.T) {
+	assert.Equal(t, 100.0, relativeHumidity(12, 12))
+	assert.InDelta(t, 64.3, relativeHumidity(21, 14), 0.1)
+	assert.InDelta(t, 79.9, relativeHumidity(-2, -5), 0.1)
+}
@@ -0,0 +1,292 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// NwsUrl is the base url of the US National Weather Service API.
+const NwsUrl = "https://api.weather.gov"
+
+// nwsStationTTL is how long the nearest station of a point is kept, stations hardly ever move.
+const nwsStationTTL = time.Hour * 24
+
+// NewNwsWeatherProvider creates a provider of the US National Weather Service API at the base url, e.g. NwsUrl.
+// The API asks for a user agent identifying the application along with a contact.
+// NWS looks locations up by coordinates only, and covers the United States only.
+//
+// A point is resolved to its grid office and the stations observing it, the nearest of which is kept
+// for nwsStationTTL, and the weather is the latest observation of that station.
+func NewNwsWeatherProvider(client http.Client, baseUrl string, userAgent string) weather.Provider {
+	return &nwsWeatherProvider{
+		client:    client,
+		baseUrl:   baseUrl,
+		userAgent: userAgent,
+		stations:  cache.New(nwsStationTTL, nwsStationTTL),
+	}
+}
+
+type nwsWeatherProvider struct {
+	client    http.Client
+	baseUrl   string
+	userAgent string
+	// stations maps points to their nearest station, or to "" when they are not covered by NWS
+	stations *cache.Cache
+}
+
+func (p *nwsWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	if location.Coordinates == nil {
+		return weather.Weather{}, errors.Wrapf(weather.ErrNotSupported, "nws: %v has no coordinates", location)
+	}
+	station, err := p.station(ctx, *location.Coordinates)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "nws: failed to get %v station", location)
+	}
+	body, err := p.request(ctx, p.baseUrl+"/stations/"+url.PathEscape(station)+"/observations/latest")
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "nws: failed to get %v weather", location)
+	}
+	defer body.Close()
+	return p.toWeather(body)
+}
+
+// station returns the identifier of the station nearest to the coordinates.
+func (p *nwsWeatherProvider) station(ctx context.Context, coordinates weather.Coordinates) (string, error) {
+	// the API redirects points of more than 4 decimals
+	point := fmt.Sprintf("%.4f,%.4f", coordinates.Latitude, coordinates.Longitude)
+	if station, ok := p.stations.Get(point); ok {
+		if station == "" {
+			return "", errors.Wrapf(weather.ErrNotSupported, "%v is not covered", point)
+		}
+		return station.(string), nil
+	}
+
+	body, err := p.request(ctx, p.baseUrl+"/points/"+point)
+	if errors.Cause(err) == errNwsNotFound {
+		p.stations.SetDefault(point, "")
+		return "", errors.Wrapf(weather.ErrNotSupported, "%v is not covered", point)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get point")
+	}
+	defer body.Close()
+	var points nwsPoint
+	if err := json.NewDecoder(body).Decode(&points); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal point json response")
+	}
+	if points.Properties.ObservationStations == "" {
+		return "", errors.Errorf("point %v has no observation stations", point)
+	}
+
+	stationsBody, err := p.request(ctx, points.Properties.ObservationStations)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get stations")
+	}
+	defer stationsBody.Close()
+	var stations nwsStations
+	if err := json.NewDecoder(stationsBody).Decode(&stations); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal stations json response")
+	}
+	// stations are sorted by their distance to the point
+	if len(stations.Features) == 0 || stations.Features[0].Properties.StationIdentifier == "" {
+		p.stations.SetDefault(point, "")
+		return "", errors.Wrapf(weather.ErrNotSupported, "%v has no station", point)
+	}
+	station := stations.Features[0].Properties.StationIdentifier
+	log.WithField("point", point).
+		WithField("station", station).
+		WithField("provider", "nws").
+		Debug("resolved point to station")
+	p.stations.SetDefault(point, station)
+	return station, nil
+}
+
+// errNwsNotFound is returned by request when the API has no data of the url, e.g. of points out of the country.
+var errNwsNotFound = errors.New("not found")
+
+func (p *nwsWeatherProvider) request(ctx context.Context, urlString string) (io.ReadCloser, error) {
+	log.WithField("url", urlString).
+		WithField("provider", "nws").
+		Debug("sending http request")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	request.Header.Set("User-Agent", p.userAgent)
+	request.Header.Set("Accept", "application/geo+json")
+	r, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode == http.StatusNotFound {
+		r.Body.Close()
+		return nil, errNwsNotFound
+	}
+	if err := checkResponse(r); err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+type nwsPoint struct {
+	Properties struct {
+		// ObservationStations is the url of the stations observing the grid of the point
+		ObservationStations string `json:"observationStations"`
+	} `json:"properties"`
+}
+
+type nwsStations struct {
+	Features []struct {
+		Properties struct {
+			StationIdentifier string `json:"stationIdentifier"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+type nwsObservation struct {
+	Properties struct {
+		Timestamp          time.Time      `json:"timestamp"`
+		TextDescription    string         `json:"textDescription"`
+		Temperature        nwsMeasurement `json:"temperature"`
+		WindDirection      nwsMeasurement `json:"windDirection"`
+		WindSpeed          nwsMeasurement `json:"windSpeed"`
+		WindGust           nwsMeasurement `json:"windGust"`
+		BarometricPressure nwsMeasurement `json:"barometricPressure"`
+		SeaLevelPressure   nwsMeasurement `json:"seaLevelPressure"`
+		Visibility         nwsMeasurement `json:"visibility"`
+		RelativeHumidity   nwsMeasurement `json:"relativeHumidity"`
+	} `json:"properties"`
+}
+
+// nwsMeasurement is a value along with its unit code, e.g. "wmoUnit:degC". Missing values are null.
+type nwsMeasurement struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+// nwsConversions convert values of unit codes, without their "wmoUnit:" prefix, into the unit of a measurement.
+type nwsConversions map[string]func(float64) float64
+
+func unchanged(value float64) float64 {
+	return value
+}
+
+var (
+	nwsTemperatureConversions = nwsConversions{
+		"degC": unchanged,
+		"degF": func(value float64) float64 {
+			return weather.ConvertTemperature(value, weather.Fahrenheit, weather.Celsius)
+		},
+		"K": func(value float64) float64 { return weather.ConvertTemperature(value, weather.Kelvin, weather.Celsius) },
+	}
+	nwsSpeedConversions = nwsConversions{
+		"km_h-1": unchanged,
+		"m_s-1":  func(value float64) float64 { return weather.ToWindSpeed(value, weather.MetresPerSecond) },
+		"kt":     func(value float64) float64 { return weather.ToWindSpeed(value, weather.Knots) },
+	}
+	nwsPressureConversions = nwsConversions{
+		"Pa":  func(value float64) float64 { return value / 100 },
+		"hPa": unchanged,
+	}
+	nwsDistanceConversions = nwsConversions{
+		"m":  func(value float64) float64 { return value / 1000 },
+		"km": unchanged,
+	}
+	nwsAngleConversions   = nwsConversions{"degree_(angle)": unchanged}
+	nwsPercentConversions = nwsConversions{"percent": unchanged}
+)
+
+// in converts the measurement with the conversion of its unit code, failing for unknown unit codes.
+func (m nwsMeasurement) in(conversions nwsConversions, name string) (*float64, error) {
+	if m.Value == nil {
+		return nil, nil
+	}
+	unit := m.UnitCode[strings.LastIndex(m.UnitCode, ":")+1:]
+	convert, ok := conversions[unit]
+	if !ok {
+		return nil, errors.Errorf("nws: unknown unit %q of %v", m.UnitCode, name)
+	}
+	value := convert(*m.Value)
+	return &value, nil
+}
+
+func (p *nwsWeatherProvider) toWeather(data io.Reader) (weather.Weather, error) {
+	var response nwsObservation
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Weather{}, errors.Wrap(err, "nws: failed to unmarshal json response")
+	}
+	observation := response.Properties
+	var windSpeed, temperature, windGust, windDirection, humidity, pressure, seaLevelPressure, visibility *float64
+	for _, m := range []struct {
+		measurement nwsMeasurement
+		conversions nwsConversions
+		name        string
+		value       **float64
+		required    bool
+	}{
+		{observation.WindSpeed, nwsSpeedConversions, "wind speed", &windSpeed, true},
+		{observation.Temperature, nwsTemperatureConversions, "temperature", &temperature, true},
+		{observation.WindGust, nwsSpeedConversions, "wind gust", &windGust, false},
+		{observation.WindDirection, nwsAngleConversions, "wind direction", &windDirection, false},
+		{observation.RelativeHumidity, nwsPercentConversions, "humidity", &humidity, false},
+		{observation.BarometricPressure, nwsPressureConversions, "pressure", &pressure, false},
+		{observation.SeaLevelPressure, nwsPressureConversions, "sea level pressure", &seaLevelPressure, false},
+		{observation.Visibility, nwsDistanceConversions, "visibility", &visibility, false},
+	} {
+		value, err := m.measurement.in(m.conversions, m.name)
+		if err != nil && m.required {
+			return weather.Weather{}, err
+		}
+		if err != nil {
+			// an optional measurement in an unexpected unit is left out rather than failing the observation
+			log.WithField("error", err).
+				WithField("provider", "nws").
+				Warn("leaving measurement out")
+			continue
+		}
+		*m.value = value
+	}
+	if windSpeed == nil {
+		return weather.Weather{}, errors.New("nws: failed to extract wind speed from response")
+	}
+	if temperature == nil {
+		return weather.Weather{}, errors.New("nws: failed to extract temperature degrees from response")
+	}
+	// pressure is reported at sea level by the other providers
+	if seaLevelPressure != nil {
+		pressure = seaLevelPressure
+	}
+	w := weather.Weather{
+		WindSpeed:          *windSpeed,
+		TemperatureDegrees: *temperature,
+		Units:              weather.CanonicalUnits,
+		WindGust:           windGust,
+		WindDirection:      windDirection,
+		Humidity:           humidity,
+		Pressure:           pressure,
+		Visibility:         visibility,
+		Provider:           "nws",
+	}
+	if observation.TextDescription != "" {
+		w.Condition = &weather.Condition{Code: observation.TextDescription, Description: strings.ToLower(observation.TextDescription)}
+	}
+	if !observation.Timestamp.IsZero() {
+		observedAt := observation.Timestamp.UTC()
+		w.ObservedAt = &observedAt
+	}
+	log.WithField("weather", w).
+		WithField("provider", "nws").
+		Debug("got weather data")
+	return w, nil
+}
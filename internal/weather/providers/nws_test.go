@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+var seattle = weather.Location{City: "Seattle", Country: "US", Coordinates: &weather.Coordinates{Latitude: 47.6062, Longitude: -122.3321}}
+
+const (
+	nwsPointBody       = `{"properties":{"gridId":"SEW","observationStations":"https://nws.test/gridpoints/SEW/124,67/stations"}}`
+	nwsStationsBody    = `{"features":[{"properties":{"stationIdentifier":"KBFI"}},{"properties":{"stationIdentifier":"KSEA"}}]}`
+	nwsObservationBody = `{"properties":{"timestamp":"2018-10-22T09:53:00+00:00","textDescription":"Mostly Cloudy",
+		"temperature":{"unitCode":"wmoUnit:degC","value":12.2,"qualityControl":"V"},
+		"windDirection":{"unitCode":"wmoUnit:degree_(angle)","value":180},
+		"windSpeed":{"unitCode":"wmoUnit:km_h-1","value":18.36},
+		"windGust":{"unitCode":"wmoUnit:km_h-1","value":null},
+		"barometricPressure":{"unitCode":"wmoUnit:Pa","value":101520},
+		"seaLevelPressure":{"unitCode":"wmoUnit:Pa","value":101550},
+		"visibility":{"unitCode":"wmoUnit:m","value":16090},
+		"relativeHumidity":{"unitCode":"wmoUnit:percent","value":81.5}}}`
+)
+
+// nwsServerStub answers requests by their path, counting requests of every path.
+type nwsServerStub struct {
+	responses map[string]string
+	requests  map[string]int
+}
+
+func newNwsServerStub() *nwsServerStub {
+	return &nwsServerStub{
+		responses: map[string]string{
+			"/points/47.6062,-122.3321":          nwsPointBody,
+			"/gridpoints/SEW/124,67/stations":    nwsStationsBody,
+			"/stations/KBFI/observations/latest": nwsObservationBody,
+		},
+		requests: make(map[string]int),
+	}
+}
+
+func (s *nwsServerStub) client(requestAsserts ...func(*http.Request)) http.Client {
+	return http.Client{Transport: promhttp.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		for _, assertFunc := range requestAsserts {
+			assertFunc(req)
+		}
+		s.requests[req.URL.Path]++
+		body, ok := s.responses[req.URL.Path]
+		statusCode := http.StatusOK
+		if !ok {
+			statusCode = http.StatusNotFound
+		}
+		return &http.Response{
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			StatusCode: statusCode,
+			Header:     make(http.Header),
+		}, nil
+	})}
+}
+
+func Test_Should_Send_Nws_Requests_With_User_Agent(t *testing.T) {
+	server := newNwsServerStub()
+	client := server.client(func(req *http.Request) {
+		assert.Equal(t, "nws.test", req.URL.Host)
+		assert.Equal(t, "weather-reporter test@example.com", req.Header.Get("User-Agent"))
+		assert.Equal(t, "application/geo+json", req.Header.Get("Accept"))
+	})
+	provider := NewNwsWeatherProvider(client, "https://nws.test", "weather-reporter test@example.com")
+	_, err := provider.Get(context.Background(), seattle)
+	assert.NoError(t, err)
+}
+
+func Test_Should_Return_Latest_Observation_Of_Nearest_Nws_Station(t *testing.T) {
+	server := newNwsServerStub()
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	w, err := provider.Get(context.Background(), seattle)
+	assert.NoError(t, err)
+	observedAt := time.Date(2018, 10, 22, 9, 53, 0, 0, time.UTC)
+	assert.Equal(t, weather.Weather{
+		WindSpeed:          18.36,
+		TemperatureDegrees: 12.2,
+		Units:              weather.CanonicalUnits,
+		WindDirection:      float(180),
+		Humidity:           float(81.5),
+		Pressure:           float(1015.5),
+		Visibility:         float(16.09),
+		Condition:          &weather.Condition{Code: "Mostly Cloudy", Description: "mostly cloudy"},
+		ObservedAt:         &observedAt,
+		Provider:           "nws",
+	}, w)
+	assert.Equal(t, 1, server.requests["/stations/KBFI/observations/latest"])
+}
+
+func Test_Should_Keep_Nws_Point_Station(t *testing.T) {
+	server := newNwsServerStub()
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	_, _ = provider.Get(context.Background(), seattle)
+	_, err := provider.Get(context.Background(), seattle)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.requests["/points/47.6062,-122.3321"])
+	assert.Equal(t, 1, server.requests["/gridpoints/SEW/124,67/stations"])
+	assert.Equal(t, 2, server.requests["/stations/KBFI/observations/latest"])
+}
+
+func Test_Should_Not_Support_Points_Out_Of_Nws_Coverage(t *testing.T) {
+	server := newNwsServerStub()
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+	_, err = provider.Get(context.Background(), sydney)
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+	assert.Equal(t, 1, server.requests["/points/-33.8688,151.2093"])
+}
+
+func Test_Should_Not_Support_Nws_Lookup_Without_Coordinates(t *testing.T) {
+	client := NewClientStub("", 0, nil, func(req *http.Request) {
+		t.Fatal("nws must not be called without coordinates")
+	})
+	provider := NewNwsWeatherProvider(client, NwsUrl, "test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "Seattle"})
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Not_Keep_Nws_Point_When_Request_Fails(t *testing.T) {
+	msg := "test error message"
+	provider := NewNwsWeatherProvider(NewClientStub("", 0, errors.New(msg)), NwsUrl, "test")
+	_, err := provider.Get(context.Background(), seattle)
+	assert.Contains(t, err.Error(), msg)
+	_, ok := provider.(*nwsWeatherProvider).stations.Get("47.6062,-122.3321")
+	assert.False(t, ok)
+}
+
+func Test_Should_Return_Error_When_Nws_Response_Status_Is_Not_OK(t *testing.T) {
+	provider := NewNwsWeatherProvider(NewClientStub("", 500, nil), NwsUrl, "test")
+	_, err := provider.Get(context.Background(), seattle)
+	assert.Contains(t, err.Error(), "request failed")
+}
+
+func Test_Should_Return_Error_When_Nws_Observation_Has_No_Temperature(t *testing.T) {
+	server := newNwsServerStub()
+	server.responses["/stations/KBFI/observations/latest"] =
+		`{"properties":{"windSpeed":{"unitCode":"wmoUnit:km_h-1","value":1},"temperature":{"unitCode":"wmoUnit:degC","value":null}}}`
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	_, err := provider.Get(context.Background(), seattle)
+	assert.Contains(t, err.Error(), "failed to extract temperature")
+}
+
+func Test_Should_Return_Error_When_Nws_Observation_Has_Unknown_Unit(t *testing.T) {
+	server := newNwsServerStub()
+	server.responses["/stations/KBFI/observations/latest"] =
+		`{"properties":{"windSpeed":{"unitCode":"wmoUnit:furlong_fortnight-1","value":1}}}`
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	_, err := provider.Get(context.Background(), seattle)
+	assert.Contains(t, err.Error(), `unknown unit "wmoUnit:furlong_fortnight-1" of wind speed`)
+}
+
+func Test_Should_Leave_Out_Optional_Nws_Measurement_Of_Unknown_Unit(t *testing.T) {
+	server := newNwsServerStub()
+	server.responses["/stations/KBFI/observations/latest"] = `{"properties":{
+		"windSpeed":{"unitCode":"wmoUnit:km_h-1","value":1},
+		"temperature":{"unitCode":"wmoUnit:degC","value":12},
+		"visibility":{"unitCode":"wmoUnit:furlong","value":80},
+		"relativeHumidity":{"unitCode":"wmoUnit:percent","value":70}}}`
+	provider := NewNwsWeatherProvider(server.client(), "https://nws.test", "test")
+	w, err := provider.Get(context.Background(), seattle)
+	assert.NoError(t, err)
+	assert.Nil(t, w.Visibility)
+	assert.Equal(t, float(70), w.Humidity)
+	assert.Equal(t, 12.0, w.TemperatureDegrees)
+}
+
+func Test_Should_Convert_Nws_Unit_Codes(t *testing.T) {
+	value := func(m nwsMeasurement, conversions nwsConversions) float64 {
+		converted, err := m.in(conversions, "test")
+		assert.NoError(t, err)
+		return *converted
+	}
+	assert.Equal(t, 20.0, value(nwsMeasurement{"wmoUnit:degF", float(68)}, nwsTemperatureConversions))
+	assert.InDelta(t, 36.0, value(nwsMeasurement{"wmoUnit:m_s-1", float(10)}, nwsSpeedConversions), 1e-9)
+	assert.InDelta(t, 18.52, value(nwsMeasurement{"wmoUnit:kt", float(10)}, nwsSpeedConversions), 1e-9)
+	assert.Equal(t, 12.0, value(nwsMeasurement{"unit:degC", float(12)}, nwsTemperatureConversions))
+	missing, err := nwsMeasurement{"wmoUnit:degC", nil}.in(nwsTemperatureConversions, "test")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,284 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// OpenMeteoUrl is the base url of the Open-Meteo API, which requires no API key.
+const OpenMeteoUrl = "https://api.open-meteo.com"
+
+// openMeteoWeatherCodes describes the WMO weather interpretation codes reported by Open-Meteo.
+var openMeteoWeatherCodes = map[int]string{
+	0:  "clear sky",
+	1:  "mainly clear",
+	2:  "partly cloudy",
+	3:  "overcast",
+	45: "fog",
+	48: "depositing rime fog",
+	51: "light drizzle",
+	53: "moderate drizzle",
+	55: "dense drizzle",
+	56: "light freezing drizzle",
+	57: "dense freezing drizzle",
+	61: "slight rain",
+	63: "moderate rain",
+	65: "heavy rain",
+	66: "light freezing rain",
+	67: "heavy freezing rain",
+	71: "slight snow fall",
+	73: "moderate snow fall",
+	75: "heavy snow fall",
+	77: "snow grains",
+	80: "slight rain showers",
+	81: "moderate rain showers",
+	82: "violent rain showers",
+	85: "slight snow showers",
+	86: "heavy snow showers",
+	95: "thunderstorm",
+	96: "thunderstorm with slight hail",
+	99: "thunderstorm with heavy hail",
+}
+
+// NewOpenMeteoWeatherProvider creates a provider of the Open-Meteo API at the base url, e.g. OpenMeteoUrl.
+// Open-Meteo looks locations up by coordinates only.
+func NewOpenMeteoWeatherProvider(client http.Client, baseUrl string) weather.Provider {
+	return &openMeteoWeatherProvider{
+		client:  client,
+		baseUrl: baseUrl,
+	}
+}
+
+type openMeteoWeatherProvider struct {
+	client  http.Client
+	baseUrl string
+}
+
+func (p *openMeteoWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	params := url.Values{}
+	params.Set("current", "temperature_2m,relative_humidity_2m,weather_code,cloud_cover,pressure_msl,"+
+		"wind_speed_10m,wind_direction_10m,wind_gusts_10m")
+	params.Set("timeformat", "unixtime")
+	body, err := p.request(ctx, location, params)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "openMeteo: failed to get %v weather", location)
+	}
+	defer body.Close()
+	return p.toWeather(body)
+}
+
+func (p *openMeteoWeatherProvider) GetForecast(ctx context.Context, location weather.Location, days int) (weather.Forecast, error) {
+	params := url.Values{}
+	params.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,wind_speed_10m_max")
+	params.Set("forecast_days", strconv.Itoa(days))
+	// days are dated in the local time of the location
+	params.Set("timezone", "auto")
+	body, err := p.request(ctx, location, params)
+	if err != nil {
+		return weather.Forecast{}, errors.Wrapf(err, "openMeteo: failed to get %v forecast", location)
+	}
+	defer body.Close()
+	return p.toForecast(body)
+}
+
+func (p *openMeteoWeatherProvider) GetHourlyForecast(ctx context.Context, location weather.Location, hours int) (weather.HourlyForecast, error) {
+	params := url.Values{}
+	params.Set("hourly", "temperature_2m,wind_speed_10m,precipitation_probability")
+	// hours are counted from the current hour
+	params.Set("forecast_hours", strconv.Itoa(hours))
+	params.Set("timezone", "auto")
+	params.Set("timeformat", "unixtime")
+	body, err := p.request(ctx, location, params)
+	if err != nil {
+		return weather.HourlyForecast{}, errors.Wrapf(err, "openMeteo: failed to get %v hourly forecast", location)
+	}
+	defer body.Close()
+	return p.toHourlyForecast(body)
+}
+
+func (p *openMeteoWeatherProvider) request(ctx context.Context, location weather.Location, params url.Values) (io.ReadCloser, error) {
+	if location.Coordinates == nil {
+		return nil, errors.Wrap(weather.ErrNotSupported, "location has no coordinates")
+	}
+	params.Set("latitude", strconv.FormatFloat(location.Coordinates.Latitude, 'f', -1, 64))
+	params.Set("longitude", strconv.FormatFloat(location.Coordinates.Longitude, 'f', -1, 64))
+	params.Set("wind_speed_unit", "kmh")
+	urlString := p.baseUrl + "/v1/forecast?" + params.Encode()
+	log.WithField("url", urlString).
+		WithField("provider", "openMeteo").
+		Debug("sending http request")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	r, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(r); err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+type openMeteoWeather struct {
+	Current struct {
+		// Time is in unix seconds
+		Time          int64    `json:"time"`
+		Temperature   *float64 `json:"temperature_2m"`
+		Humidity      *float64 `json:"relative_humidity_2m"`
+		WeatherCode   *int     `json:"weather_code"`
+		CloudCover    *float64 `json:"cloud_cover"`
+		Pressure      *float64 `json:"pressure_msl"`
+		WindSpeed     *float64 `json:"wind_speed_10m"`
+		WindDirection *float64 `json:"wind_direction_10m"`
+		WindGust      *float64 `json:"wind_gusts_10m"`
+	} `json:"current"`
+}
+
+func (p *openMeteoWeatherProvider) toWeather(data io.Reader) (weather.Weather, error) {
+	var response openMeteoWeather
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Weather{}, errors.Wrap(err, "openMeteo: failed to unmarshal json response")
+	}
+	current := response.Current
+	if current.WindSpeed == nil {
+		return weather.Weather{}, errors.New("openMeteo: failed to extract wind speed from response")
+	}
+	if current.Temperature == nil {
+		return weather.Weather{}, errors.New("openMeteo: failed to extract temperature degrees from response")
+	}
+	// wind speed is requested in km/h
+	w := weather.Weather{
+		WindSpeed:          *current.WindSpeed,
+		TemperatureDegrees: *current.Temperature,
+		Units:              weather.CanonicalUnits,
+		WindGust:           current.WindGust,
+		WindDirection:      current.WindDirection,
+		Humidity:           current.Humidity,
+		Pressure:           current.Pressure,
+		CloudCover:         current.CloudCover,
+		Condition:          openMeteoCondition(current.WeatherCode),
+		Provider:           "openMeteo",
+	}
+	if current.Time != 0 {
+		t := time.Unix(current.Time, 0).UTC()
+		w.ObservedAt = &t
+	}
+	log.WithField("weather", w).
+		WithField("provider", "openMeteo").
+		Debug("got weather data")
+	return w, nil
+}
+
+func openMeteoCondition(code *int) *weather.Condition {
+	if code == nil {
+		return nil
+	}
+	return &weather.Condition{Code: strconv.Itoa(*code), Description: openMeteoWeatherCodes[*code]}
+}
+
+type openMeteoForecast struct {
+	Daily struct {
+		// Time is the local date of every day
+		Time           []string   `json:"time"`
+		WeatherCode    []*int     `json:"weather_code"`
+		TemperatureMax []*float64 `json:"temperature_2m_max"`
+		TemperatureMin []*float64 `json:"temperature_2m_min"`
+		WindSpeedMax   []*float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
+}
+
+func (p *openMeteoWeatherProvider) toForecast(data io.Reader) (weather.Forecast, error) {
+	var response openMeteoForecast
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Forecast{}, errors.Wrap(err, "openMeteo: failed to unmarshal json response")
+	}
+	daily := response.Daily
+	forecast := weather.Forecast{Units: weather.CanonicalUnits, Provider: "openMeteo"}
+	for i, date := range daily.Time {
+		temperatureMin := optionalFloatAt(daily.TemperatureMin, i)
+		temperatureMax := optionalFloatAt(daily.TemperatureMax, i)
+		if temperatureMin == nil || temperatureMax == nil {
+			return weather.Forecast{}, errors.Errorf("openMeteo: failed to extract temperature from forecast of %v", date)
+		}
+		day := weather.DailyForecast{
+			Date:           date,
+			TemperatureMin: *temperatureMin,
+			TemperatureMax: *temperatureMax,
+			WindSpeed:      optionalFloatAt(daily.WindSpeedMax, i),
+		}
+		if i < len(daily.WeatherCode) {
+			day.Condition = openMeteoCondition(daily.WeatherCode[i])
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+	if len(forecast.Days) == 0 {
+		return weather.Forecast{}, errors.New("openMeteo: forecast has no days")
+	}
+	log.WithField("forecast", forecast).
+		WithField("provider", "openMeteo").
+		Debug("got forecast data")
+	return forecast, nil
+}
+
+type openMeteoHourlyForecast struct {
+	// UtcOffsetSeconds is the shift of the location local time from UTC
+	UtcOffsetSeconds int `json:"utc_offset_seconds"`
+	Hourly           struct {
+		// Time is in unix seconds
+		Time                     []int64    `json:"time"`
+		Temperature              []*float64 `json:"temperature_2m"`
+		WindSpeed                []*float64 `json:"wind_speed_10m"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+	} `json:"hourly"`
+}
+
+func (p *openMeteoWeatherProvider) toHourlyForecast(data io.Reader) (weather.HourlyForecast, error) {
+	var response openMeteoHourlyForecast
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.HourlyForecast{}, errors.Wrap(err, "openMeteo: failed to unmarshal json response")
+	}
+	timeZone := weather.FormatUTCOffset(response.UtcOffsetSeconds)
+	location := time.FixedZone(timeZone, response.UtcOffsetSeconds)
+	forecast := weather.HourlyForecast{TimeZone: timeZone, Units: weather.CanonicalUnits, Provider: "openMeteo"}
+	hourly := response.Hourly
+	for i, at := range hourly.Time {
+		temperature := optionalFloatAt(hourly.Temperature, i)
+		windSpeed := optionalFloatAt(hourly.WindSpeed, i)
+		if temperature == nil || windSpeed == nil {
+			return weather.HourlyForecast{}, errors.Errorf("openMeteo: failed to extract measurements from hourly forecast at %v", at)
+		}
+		hour := weather.NewHourlyEntry(time.Unix(at, 0), location)
+		hour.TemperatureDegrees = *temperature
+		hour.WindSpeed = *windSpeed
+		hour.PrecipitationProbability = optionalFloatAt(hourly.PrecipitationProbability, i)
+		forecast.Hours = append(forecast.Hours, hour)
+	}
+	if len(forecast.Hours) == 0 {
+		return weather.HourlyForecast{}, errors.New("openMeteo: hourly forecast has no entries")
+	}
+	log.WithField("forecast", forecast).
+		WithField("provider", "openMeteo").
+		Debug("got hourly forecast data")
+	return forecast, nil
+}
+
+// optionalFloatAt returns the value at the index of a column of Open-Meteo values, or nil when it is missing.
+func optionalFloatAt(values []*float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+var sydney = weather.Location{City: "Sydney", Country: "AU", Coordinates: &weather.Coordinates{Latitude: -33.8688, Longitude: 151.2093}}
+
+func Test_Should_Build_Open_Meteo_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "open-meteo.test", req.URL.Host)
+		assert.Equal(t, "/v1/forecast", req.URL.Path)
+		query := req.URL.Query()
+		assert.Equal(t, "-33.8688", query.Get("latitude"))
+		assert.Equal(t, "151.2093", query.Get("longitude"))
+		assert.Equal(t, "kmh", query.Get("wind_speed_unit"))
+		assert.Equal(t, "unixtime", query.Get("timeformat"))
+		assert.Contains(t, query.Get("current"), "temperature_2m")
+		assert.Contains(t, query.Get("current"), "wind_speed_10m")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenMeteoWeatherProvider(client, "https://open-meteo.test")
+	_, _ = provider.Get(context.Background(), sydney)
+}
+
+func Test_Should_Not_Support_Open_Meteo_Lookup_Without_Coordinates(t *testing.T) {
+	client := NewClientStub("", 0, nil, func(req *http.Request) {
+		t.Fatal("open-meteo must not be called without coordinates")
+	})
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), weather.Location{City: "Sydney"})
+	assert.Equal(t, weather.ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Return_Error_From_Open_Meteo_Http_Client(t *testing.T) {
+	msg := "test error message"
+	client := NewClientStub("", 0, errors.New(msg))
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), msg)
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Response_Status_Is_Not_OK(t *testing.T) {
+	client := NewClientStub("", 500, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "request failed")
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Response_Is_Not_Valid_Json(t *testing.T) {
+	client := NewClientStub("TEST", 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to unmarshal json")
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Response_Has_No_Wind_Speed(t *testing.T) {
+	client := NewClientStub(`{"current":{"temperature_2m":1}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to extract wind speed")
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Response_Has_No_Temperature(t *testing.T) {
+	client := NewClientStub(`{"current":{"wind_speed_10m":1}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, err := provider.Get(context.Background(), sydney)
+	assert.Contains(t, err.Error(), "failed to extract temperature")
+}
+
+func Test_Should_Return_Weather_From_Open_Meteo_Response(t *testing.T) {
+	client := NewClientStub(`{"current":{"time":1540202400,"interval":900,"temperature_2m":21.4,
+		"relative_humidity_2m":64,"weather_code":2,"cloud_cover":40,"pressure_msl":1015.2,
+		"wind_speed_10m":18.7,"wind_direction_10m":130,"wind_gusts_10m":31.3}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	w, err := provider.Get(context.Background(), sydney)
+	assert.NoError(t, err)
+	observedAt := time.Date(2018, 10, 22, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, weather.Weather{
+		WindSpeed:          18.7,
+		TemperatureDegrees: 21.4,
+		Units:              weather.CanonicalUnits,
+		WindGust:           float(31.3),
+		WindDirection:      float(130),
+		Humidity:           float(64),
+		Pressure:           float(1015.2),
+		CloudCover:         float(40),
+		Condition:          &weather.Condition{Code: "2", Description: "partly cloudy"},
+		ObservedAt:         &observedAt,
+		Provider:           "openMeteo",
+	}, w)
+}
+
+func Test_Should_Send_Open_Meteo_Request_With_Caller_Context(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "value", req.Context().Value(key{}))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl)
+	_, _ = provider.Get(ctx, sydney)
+}
+
+func Test_Should_Build_Open_Meteo_Forecast_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		query := req.URL.Query()
+		assert.Equal(t, "5", query.Get("forecast_days"))
+		assert.Equal(t, "auto", query.Get("timezone"))
+		assert.Contains(t, query.Get("daily"), "temperature_2m_max")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.ForecastProvider)
+	_, _ = provider.GetForecast(context.Background(), sydney, 5)
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Forecast_Has_No_Days(t *testing.T) {
+	client := NewClientStub(`{"daily":{"time":[]}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.ForecastProvider)
+	_, err := provider.GetForecast(context.Background(), sydney, 5)
+	assert.Contains(t, err.Error(), "forecast has no days")
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Forecast_Has_No_Temperature(t *testing.T) {
+	client := NewClientStub(`{"daily":{"time":["2018-10-22"],"temperature_2m_max":[null],"temperature_2m_min":[15]}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.ForecastProvider)
+	_, err := provider.GetForecast(context.Background(), sydney, 5)
+	assert.Contains(t, err.Error(), "failed to extract temperature")
+}
+
+func Test_Should_Return_Forecast_From_Open_Meteo_Response(t *testing.T) {
+	client := NewClientStub(`{"daily":{"time":["2018-10-22","2018-10-23"],"weather_code":[61,null],
+		"temperature_2m_max":[24.5,19],"temperature_2m_min":[15,12],"wind_speed_10m_max":[18]}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.ForecastProvider)
+	forecast, err := provider.GetForecast(context.Background(), sydney, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, weather.Forecast{
+		Days: []weather.DailyForecast{
+			{Date: "2018-10-22", TemperatureMin: 15, TemperatureMax: 24.5, WindSpeed: float(18),
+				Condition: &weather.Condition{Code: "61", Description: "slight rain"}},
+			{Date: "2018-10-23", TemperatureMin: 12, TemperatureMax: 19},
+		},
+		Units:    weather.CanonicalUnits,
+		Provider: "openMeteo",
+	}, forecast)
+}
+
+func Test_Should_Build_Open_Meteo_Hourly_Forecast_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		query := req.URL.Query()
+		assert.Equal(t, "48", query.Get("forecast_hours"))
+		assert.Equal(t, "unixtime", query.Get("timeformat"))
+		assert.Contains(t, query.Get("hourly"), "precipitation_probability")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.HourlyForecastProvider)
+	_, _ = provider.GetHourlyForecast(context.Background(), sydney, 48)
+}
+
+func Test_Should_Return_Error_When_Open_Meteo_Hourly_Forecast_Has_No_Entries(t *testing.T) {
+	client := NewClientStub(`{"utc_offset_seconds":0,"hourly":{"time":[]}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.HourlyForecastProvider)
+	_, err := provider.GetHourlyForecast(context.Background(), sydney, 48)
+	assert.Contains(t, err.Error(), "no entries")
+}
+
+func Test_Should_Return_Open_Meteo_Hourly_Forecast_In_Local_Time(t *testing.T) {
+	client := NewClientStub(`{"utc_offset_seconds":39600,"hourly":{"time":[1540202400,1540206000],
+		"temperature_2m":[20.5,21],"wind_speed_10m":[18,36],"precipitation_probability":[25,null]}}`, 200, nil)
+	provider := NewOpenMeteoWeatherProvider(client, OpenMeteoUrl).(weather.HourlyForecastProvider)
+	forecast, err := provider.GetHourlyForecast(context.Background(), sydney, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "+11:00", forecast.TimeZone)
+	assert.Equal(t, "openMeteo", forecast.Provider)
+	assert.Len(t, forecast.Hours, 2)
+	assert.Equal(t, "2018-10-22T10:00:00Z", forecast.Hours[0].Time.Format(time.RFC3339))
+	assert.Equal(t, "2018-10-22T21:00:00+11:00", forecast.Hours[0].LocalTime.Format(time.RFC3339))
+	assert.Equal(t, 20.5, forecast.Hours[0].TemperatureDegrees)
+	assert.Equal(t, 18.0, forecast.Hours[0].WindSpeed)
+	assert.Equal(t, 25.0, *forecast.Hours[0].PrecipitationProbability)
+	assert.Nil(t, forecast.Hours[1].PrecipitationProbability)
+}
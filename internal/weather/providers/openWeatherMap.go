@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/oliveagle/jsonpath"
 	"github.com/pkg/errors"
@@ -9,11 +10,16 @@ import (
 	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 	"weather-reporter/internal/weather"
 )
 
-const openWeatherMapUrl = "http://api.openweathermap.org/data/2.5/weather"
+const openWeatherMapUrl = "http://api.openweathermap.org/data/2.5"
+
+// openWeatherMapProUrl serves the hourly forecast, which is available to paid plans only
+const openWeatherMapProUrl = "https://pro.openweathermap.org/data/2.5"
 
 func NewOpenWeatherMapWeatherProvider(client http.Client, appID string) weather.Provider {
 	return &openWeatherMapWeatherProvider{
@@ -27,24 +33,71 @@ type openWeatherMapWeatherProvider struct {
 	appID  string
 }
 
-func (p *openWeatherMapWeatherProvider) Get(city string) (weather.Weather, error) {
+func (p *openWeatherMapWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	body, err := p.request(ctx, openWeatherMapUrl+"/weather", location)
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "openWeatherMap: failed to get %v weather", location)
+	}
+	defer body.Close()
+	return p.toWeather(body)
+}
+
+func (p *openWeatherMapWeatherProvider) GetForecast(ctx context.Context, location weather.Location, days int) (weather.Forecast, error) {
+	body, err := p.request(ctx, openWeatherMapUrl+"/forecast", location)
+	if err != nil {
+		return weather.Forecast{}, errors.Wrapf(err, "openWeatherMap: failed to get %v forecast", location)
+	}
+	defer body.Close()
+	return p.toForecast(body, days)
+}
+
+func (p *openWeatherMapWeatherProvider) GetHourlyForecast(ctx context.Context, location weather.Location, hours int) (weather.HourlyForecast, error) {
+	body, err := p.request(ctx, openWeatherMapProUrl+"/forecast/hourly", location)
+	if err != nil {
+		return weather.HourlyForecast{}, errors.Wrapf(err, "openWeatherMap: failed to get %v hourly forecast", location)
+	}
+	defer body.Close()
+	return p.toHourlyForecast(body, hours)
+}
+
+func (p *openWeatherMapWeatherProvider) request(ctx context.Context, endpoint string, location weather.Location) (io.ReadCloser, error) {
 	params := url.Values{}
 	params.Set("appid", p.appID)
 	params.Set("units", "metric")
-	params.Set("q", strings.ToLower(city))
-	urlString := openWeatherMapUrl + "?" + params.Encode()
+	if location.Coordinates != nil {
+		params.Set("lat", strconv.FormatFloat(location.Coordinates.Latitude, 'f', -1, 64))
+		params.Set("lon", strconv.FormatFloat(location.Coordinates.Longitude, 'f', -1, 64))
+	} else if location.Country != "" {
+		params.Set("q", strings.ToLower(location.City+","+location.Country))
+	} else {
+		params.Set("q", strings.ToLower(location.City))
+	}
+	urlString := endpoint + "?" + params.Encode()
 	log.WithField("url", urlString).
 		WithField("provider", "openWeatherMap").
 		Debug("sending http request")
-	r, err := p.client.Get(urlString)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	r, err := p.client.Do(request)
 	if err != nil {
-		return weather.Weather{}, errors.Wrapf(err, "openWeatherMap: failed to get %v weather", city)
+		return nil, err
+	}
+	if strings.HasPrefix(endpoint, openWeatherMapProUrl) &&
+		(r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden) {
+		// the app id is of a free plan, which is no failure of the provider
+		r.Body.Close()
+		return nil, errors.Wrapf(weather.ErrNotSupported, "%v is not in the plan of the app id", endpoint)
+	}
+	if r.StatusCode == http.StatusNotFound {
+		r.Body.Close()
+		return nil, errors.Wrap(weather.ErrUnknownLocation, "no city matched")
 	}
-	if r.StatusCode != 200 {
-		return weather.Weather{}, errors.Errorf("openWeatherMap: request failed with message: %v", r.Status)
+	if err := checkResponse(r); err != nil {
+		return nil, err
 	}
-	defer r.Body.Close()
-	return p.toWeather(r.Body)
+	return r.Body, nil
 }
 
 func (p *openWeatherMapWeatherProvider) toWeather(data io.Reader) (weather.Weather, error) {
@@ -61,12 +114,159 @@ func (p *openWeatherMapWeatherProvider) toWeather(data io.Reader) (weather.Weath
 	if err != nil {
 		return weather.Weather{}, errors.Wrapf(err, "openWeatherMap: failed to extract temperature degrees from %v", jsonData)
 	}
+	// metric units of openWeatherMap have wind speed in m/s
 	w := weather.Weather{
-		WindSpeed:          int(math.Round(windSpeed.(float64))),
-		TemperatureDegrees: int(math.Round(temperatureDegrees.(float64))),
+		WindSpeed:          weather.ToWindSpeed(windSpeed.(float64), weather.MetresPerSecond),
+		TemperatureDegrees: temperatureDegrees.(float64),
+		Units:              weather.CanonicalUnits,
+		WindGust:           weather.ToOptionalWindSpeed(lookupOptionalFloat(jsonData, "$.wind.gust"), weather.MetresPerSecond),
+		WindDirection:      lookupOptionalFloat(jsonData, "$.wind.deg"),
+		Humidity:           lookupOptionalFloat(jsonData, "$.main.humidity"),
+		Pressure:           lookupOptionalFloat(jsonData, "$.main.pressure"),
+		Visibility:         scaleOptionalFloat(lookupOptionalFloat(jsonData, "$.visibility"), 0.001),
+		CloudCover:         lookupOptionalFloat(jsonData, "$.clouds.all"),
+		Provider:           "openWeatherMap",
+	}
+	if code := lookupOptionalString(jsonData, "$.weather[0].id"); code != "" {
+		w.Condition = &weather.Condition{
+			Code:        code,
+			Description: lookupOptionalString(jsonData, "$.weather[0].description"),
+		}
+	}
+	if observedAt := lookupOptionalFloat(jsonData, "$.dt"); observedAt != nil {
+		t := time.Unix(int64(*observedAt), 0).UTC()
+		w.ObservedAt = &t
 	}
 	log.WithField("weather", w).
 		WithField("provider", "openWeatherMap").
 		Debug("got weather data")
 	return w, nil
 }
+
+type openWeatherMapForecast struct {
+	List []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			TempMin *float64 `json:"temp_min"`
+			TempMax *float64 `json:"temp_max"`
+		} `json:"main"`
+		Wind struct {
+			Speed *float64 `json:"speed"`
+		} `json:"wind"`
+		Weather []struct {
+			ID          int    `json:"id"`
+			Description string `json:"description"`
+		} `json:"weather"`
+	} `json:"list"`
+	City struct {
+		// Timezone is the shift in seconds from UTC
+		Timezone int `json:"timezone"`
+	} `json:"city"`
+}
+
+// toForecast aggregates the 3-hourly forecast into days of the city local time.
+func (p *openWeatherMapWeatherProvider) toForecast(data io.Reader, days int) (weather.Forecast, error) {
+	var response openWeatherMapForecast
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Forecast{}, errors.Wrap(err, "openWeatherMap: failed to unmarshal json response")
+	}
+	location := time.FixedZone("", response.City.Timezone)
+	forecast := weather.Forecast{Units: weather.CanonicalUnits, Provider: "openWeatherMap"}
+	// middayDistance keeps the distance of the day condition from midday, as it describes the day best
+	var middayDistance int
+	for _, entry := range response.List {
+		if entry.Main.TempMin == nil || entry.Main.TempMax == nil {
+			return weather.Forecast{}, errors.Errorf("openWeatherMap: failed to extract temperature from forecast at %v", entry.Dt)
+		}
+		localTime := time.Unix(entry.Dt, 0).In(location)
+		date := localTime.Format("2006-01-02")
+		if len(forecast.Days) == 0 || forecast.Days[len(forecast.Days)-1].Date != date {
+			if len(forecast.Days) == days {
+				break
+			}
+			forecast.Days = append(forecast.Days, weather.DailyForecast{
+				Date:           date,
+				TemperatureMin: *entry.Main.TempMin,
+				TemperatureMax: *entry.Main.TempMax,
+			})
+			middayDistance = math.MaxInt32
+		}
+		day := &forecast.Days[len(forecast.Days)-1]
+		day.TemperatureMin = math.Min(day.TemperatureMin, *entry.Main.TempMin)
+		day.TemperatureMax = math.Max(day.TemperatureMax, *entry.Main.TempMax)
+		if entry.Wind.Speed != nil {
+			windSpeed := weather.ToWindSpeed(*entry.Wind.Speed, weather.MetresPerSecond)
+			if day.WindSpeed == nil || windSpeed > *day.WindSpeed {
+				day.WindSpeed = &windSpeed
+			}
+		}
+		distance := int(math.Abs(float64(localTime.Hour() - 12)))
+		if len(entry.Weather) > 0 && distance < middayDistance {
+			middayDistance = distance
+			day.Condition = &weather.Condition{
+				Code:        strconv.Itoa(entry.Weather[0].ID),
+				Description: entry.Weather[0].Description,
+			}
+		}
+	}
+	if len(forecast.Days) == 0 {
+		return weather.Forecast{}, errors.New("openWeatherMap: forecast has no entries")
+	}
+	log.WithField("forecast", forecast).
+		WithField("provider", "openWeatherMap").
+		Debug("got forecast data")
+	return forecast, nil
+}
+
+type openWeatherMapHourlyForecast struct {
+	List []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			Temp *float64 `json:"temp"`
+		} `json:"main"`
+		Wind struct {
+			Speed *float64 `json:"speed"`
+		} `json:"wind"`
+		// Pop is the probability of precipitation from 0 to 1
+		Pop *float64 `json:"pop"`
+	} `json:"list"`
+	City struct {
+		// Timezone is the shift in seconds from UTC
+		Timezone int `json:"timezone"`
+	} `json:"city"`
+}
+
+func (p *openWeatherMapWeatherProvider) toHourlyForecast(data io.Reader, hours int) (weather.HourlyForecast, error) {
+	var response openWeatherMapHourlyForecast
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.HourlyForecast{}, errors.Wrap(err, "openWeatherMap: failed to unmarshal json response")
+	}
+	location := time.FixedZone(weather.FormatUTCOffset(response.City.Timezone), response.City.Timezone)
+	forecast := weather.HourlyForecast{
+		TimeZone: weather.FormatUTCOffset(response.City.Timezone),
+		Units:    weather.CanonicalUnits,
+		Provider: "openWeatherMap",
+	}
+	for _, entry := range response.List {
+		if len(forecast.Hours) == hours {
+			break
+		}
+		if entry.Main.Temp == nil || entry.Wind.Speed == nil {
+			return weather.HourlyForecast{}, errors.Errorf("openWeatherMap: failed to extract measurements from hourly forecast at %v", entry.Dt)
+		}
+		hour := weather.NewHourlyEntry(time.Unix(entry.Dt, 0), location)
+		hour.TemperatureDegrees = *entry.Main.Temp
+		hour.WindSpeed = weather.ToWindSpeed(*entry.Wind.Speed, weather.MetresPerSecond)
+		hour.PrecipitationProbability = scaleOptionalFloat(entry.Pop, 100)
+		forecast.Hours = append(forecast.Hours, hour)
+	}
+	if len(forecast.Hours) == 0 {
+		return weather.HourlyForecast{}, errors.New("openWeatherMap: hourly forecast has no entries")
+	}
+	log.WithField("forecast", forecast).
+		WithField("provider", "openWeatherMap").
+		Debug("got hourly forecast data")
+	return forecast, nil
+}
@@ -1,11 +1,13 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
+	"time"
 	"weather-reporter/internal/weather"
 )
 
@@ -17,49 +19,236 @@ func Test_Should_Build_OWM_Api_Url(t *testing.T) {
 	}
 	client := NewClientStub("", 0, nil, assertFunc)
 	provider := NewOpenWeatherMapWeatherProvider(client, appID)
-	_, _ = provider.Get(city)
+	_, _ = provider.Get(context.Background(), weather.Location{City: city})
 }
 
 func Test_Should_Return_Error_From_OWM_Http_Client(t *testing.T) {
 	msg := "test error message"
 	client := NewClientStub("", 0, errors.New(msg))
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), msg)
 }
 
 func Test_Should_Return_Error_When_OWM_Response_Status_Is_Not_OK(t *testing.T) {
 	client := NewClientStub("", 500, nil)
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "request failed")
 }
 
+func Test_Should_Return_Unknown_Location_When_OWM_Has_No_City(t *testing.T) {
+	client := NewClientStub(`{"cod":"404","message":"city not found"}`, http.StatusNotFound, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	_, err := provider.Get(context.Background(), weather.Location{City: "atlantis"})
+	assert.Equal(t, weather.ErrUnknownLocation, errors.Cause(err))
+}
+
 func Test_Should_Return_Error_When_OWM_Response_Is_Not_Valid_Json(t *testing.T) {
 	client := NewClientStub("TEST", 200, nil)
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to unmarshal json")
 }
 
 func Test_Should_Return_Error_When_OWM_Response_Has_No_Wind_Speed(t *testing.T) {
 	client := NewClientStub(`{"main":{"temp":0}}`, 200, nil)
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to extract wind speed")
 }
 
 func Test_Should_Return_Error_When_OWM_Response_Has_No_Temperature(t *testing.T) {
 	client := NewClientStub(`{"wind":{"speed":0}}`, 200, nil)
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to extract temperature")
 }
 
 func Test_Should_Return_Weather_From_OWM_Response(t *testing.T) {
 	client := NewClientStub(`{"main":{"temp":1},"wind":{"speed":2}}`, 200, nil)
 	provider := NewOpenWeatherMapWeatherProvider(client, "")
-	w, err := provider.Get("test")
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, w.Round(0), weather.Weather{TemperatureDegrees: 1, WindSpeed: 7, Units: weather.CanonicalUnits, Provider: "openWeatherMap"})
+}
+
+func Test_Should_Convert_OWM_Wind_Speed_From_Metres_Per_Second(t *testing.T) {
+	client := NewClientStub(`{"main":{"temp":1},"wind":{"speed":10}}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 36, w.WindSpeed, 0.0001)
+	assert.Equal(t, weather.KilometresPerHour, w.Units.WindSpeed)
+}
+
+func Test_Should_Send_OWM_Request_With_Caller_Context(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "value", req.Context().Value(key{}))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	_, _ = provider.Get(ctx, weather.Location{City: "test"})
+}
+
+func Test_Should_Keep_OWM_Measurement_Precision(t *testing.T) {
+	client := NewClientStub(`{"main":{"temp":21.37},"wind":{"speed":2.1}}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.NoError(t, err)
-	assert.Equal(t, w, weather.Weather{TemperatureDegrees: 1, WindSpeed: 2})
+	assert.InDelta(t, 7.56, w.WindSpeed, 0.0001)
+	assert.Equal(t, 21.37, w.TemperatureDegrees)
+}
+
+func Test_Should_Return_Extended_Weather_From_OWM_Response(t *testing.T) {
+	client := NewClientStub(`{
+		"weather":[{"id":803,"main":"Clouds","description":"broken clouds"}],
+		"main":{"temp":1,"pressure":1012,"humidity":81},
+		"visibility":10000,
+		"wind":{"speed":2,"deg":350,"gust":5},
+		"clouds":{"all":75},
+		"dt":1540245600
+	}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 18, *w.WindGust, 0.0001)
+	assert.Equal(t, float(350), w.WindDirection)
+	assert.Equal(t, float(81), w.Humidity)
+	assert.Equal(t, float(1012), w.Pressure)
+	assert.Equal(t, float(10), w.Visibility)
+	assert.Equal(t, float(75), w.CloudCover)
+	assert.Equal(t, &weather.Condition{Code: "803", Description: "broken clouds"}, w.Condition)
+	assert.Equal(t, time.Date(2018, 10, 22, 22, 0, 0, 0, time.UTC), *w.ObservedAt)
+}
+
+func Test_Should_Build_OWM_Forecast_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "/data/2.5/forecast", req.URL.Path)
+		assert.Contains(t, req.URL.RawQuery, "appid=test-id&q=test-city&units=metric")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenWeatherMapWeatherProvider(client, "test-id").(weather.ForecastProvider)
+	_, _ = provider.GetForecast(context.Background(), weather.Location{City: "test-city"}, 5)
+}
+
+func Test_Should_Return_Error_When_OWM_Forecast_Status_Is_Not_OK(t *testing.T) {
+	client := NewClientStub("", 500, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.ForecastProvider)
+	_, err := provider.GetForecast(context.Background(), weather.Location{City: "test"}, 5)
+	assert.Contains(t, err.Error(), "request failed")
+}
+
+func Test_Should_Return_Error_When_OWM_Forecast_Has_No_Entries(t *testing.T) {
+	client := NewClientStub(`{"list":[],"city":{"timezone":0}}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.ForecastProvider)
+	_, err := provider.GetForecast(context.Background(), weather.Location{City: "test"}, 5)
+	assert.Contains(t, err.Error(), "forecast has no entries")
+}
+
+func Test_Should_Aggregate_OWM_Forecast_Into_Local_Days(t *testing.T) {
+	// 2018-10-22 13:00 UTC is 2018-10-23 00:00 in Sydney (UTC+11)
+	client := NewClientStub(`{"list":[
+		{"dt":1540202400,"main":{"temp_min":15,"temp_max":16},"wind":{"speed":2},"weather":[{"id":800,"description":"clear sky"}]},
+		{"dt":1540213200,"main":{"temp_min":14,"temp_max":15},"wind":{"speed":3},"weather":[{"id":500,"description":"light rain"}]},
+		{"dt":1540224000,"main":{"temp_min":12,"temp_max":13},"wind":{"speed":1},"weather":[{"id":801,"description":"few clouds"}]},
+		{"dt":1540256400,"main":{"temp_min":20,"temp_max":24.5},"wind":{"speed":5},"weather":[{"id":802,"description":"scattered clouds"}]},
+		{"dt":1540310400,"main":{"temp_min":18,"temp_max":19},"wind":{"speed":4},"weather":[{"id":803,"description":"broken clouds"}]}
+	],"city":{"timezone":39600}}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.ForecastProvider)
+	forecast, err := provider.GetForecast(context.Background(), weather.Location{City: "test"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "openWeatherMap", forecast.Provider)
+	assert.Equal(t, weather.CanonicalUnits, forecast.Units)
+	assert.Len(t, forecast.Days, 2)
+
+	assert.Equal(t, "2018-10-22", forecast.Days[0].Date)
+	assert.Equal(t, 15.0, forecast.Days[0].TemperatureMin)
+	assert.Equal(t, 16.0, forecast.Days[0].TemperatureMax)
+	assert.InDelta(t, 7.2, *forecast.Days[0].WindSpeed, 0.0001)
+	assert.Equal(t, &weather.Condition{Code: "800", Description: "clear sky"}, forecast.Days[0].Condition)
+
+	assert.Equal(t, "2018-10-23", forecast.Days[1].Date)
+	assert.Equal(t, 12.0, forecast.Days[1].TemperatureMin)
+	assert.Equal(t, 24.5, forecast.Days[1].TemperatureMax)
+	assert.InDelta(t, 18, *forecast.Days[1].WindSpeed, 0.0001)
+	assert.Equal(t, &weather.Condition{Code: "802", Description: "scattered clouds"}, forecast.Days[1].Condition)
+}
+
+func Test_Should_Build_OWM_Hourly_Forecast_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "pro.openweathermap.org", req.URL.Host)
+		assert.Equal(t, "/data/2.5/forecast/hourly", req.URL.Path)
+		assert.Contains(t, req.URL.RawQuery, "appid=test-id&q=test-city&units=metric")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenWeatherMapWeatherProvider(client, "test-id").(weather.HourlyForecastProvider)
+	_, _ = provider.GetHourlyForecast(context.Background(), weather.Location{City: "test-city"}, 48)
+}
+
+func Test_Should_Return_Error_When_OWM_Hourly_Forecast_Has_No_Entries(t *testing.T) {
+	client := NewClientStub(`{"list":[],"city":{"timezone":0}}`, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.HourlyForecastProvider)
+	_, err := provider.GetHourlyForecast(context.Background(), weather.Location{City: "test"}, 48)
+	assert.Contains(t, err.Error(), "no entries")
+}
+
+func Test_Should_Not_Support_OWM_Hourly_Forecast_Outside_Paid_Plan(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		client := NewClientStub(`{"cod":401}`, status, nil)
+		provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.HourlyForecastProvider)
+		_, err := provider.GetHourlyForecast(context.Background(), weather.Location{City: "test"}, 48)
+		assert.Equal(t, weather.ErrNotSupported, errors.Cause(err), status)
+	}
+}
+
+func Test_Should_Return_Error_When_OWM_Weather_Is_Unauthorized(t *testing.T) {
+	client := NewClientStub(`{"cod":401}`, http.StatusUnauthorized, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.Error(t, err)
+	assert.NotEqual(t, weather.ErrNotSupported, errors.Cause(err))
+}
+
+func Test_Should_Return_OWM_Hourly_Forecast_In_Local_Time(t *testing.T) {
+	data := `{"city":{"timezone":39600},"list":[
+		{"dt":1540202400,"main":{"temp":20.5},"wind":{"speed":5},"pop":0.25},
+		{"dt":1540206000,"main":{"temp":21},"wind":{"speed":10}},
+		{"dt":1540209600,"main":{"temp":22},"wind":{"speed":10},"pop":1}]}`
+	client := NewClientStub(data, 200, nil)
+	provider := NewOpenWeatherMapWeatherProvider(client, "").(weather.HourlyForecastProvider)
+	forecast, err := provider.GetHourlyForecast(context.Background(), weather.Location{City: "test"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "+11:00", forecast.TimeZone)
+	assert.Equal(t, "openWeatherMap", forecast.Provider)
+	assert.Len(t, forecast.Hours, 2)
+	assert.Equal(t, "2018-10-22T10:00:00Z", forecast.Hours[0].Time.Format(time.RFC3339))
+	assert.Equal(t, "2018-10-22T21:00:00+11:00", forecast.Hours[0].LocalTime.Format(time.RFC3339))
+	assert.Equal(t, 20.5, forecast.Hours[0].TemperatureDegrees)
+	assert.InDelta(t, 18, forecast.Hours[0].WindSpeed, 0.0001)
+	assert.Equal(t, 25.0, *forecast.Hours[0].PrecipitationProbability)
+	assert.Nil(t, forecast.Hours[1].PrecipitationProbability)
+}
+
+func Test_Should_Query_OWM_By_City_And_Country(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "sydney,ca", req.URL.Query().Get("q"))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	_, _ = provider.Get(context.Background(), weather.Location{City: "Sydney", Country: "CA"})
+}
+
+func Test_Should_Query_OWM_By_Coordinates(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "-33.8688", req.URL.Query().Get("lat"))
+		assert.Equal(t, "151.2093", req.URL.Query().Get("lon"))
+		assert.Empty(t, req.URL.Query().Get("q"))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewOpenWeatherMapWeatherProvider(client, "")
+	location := weather.Location{Coordinates: &weather.Coordinates{Latitude: -33.8688, Longitude: 151.2093}}
+	_, _ = provider.Get(context.Background(), location)
 }
@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+// checkResponse returns an error for a response that is not OK, closing its body.
+// Responses refused for too many requests are reported as rate limited, along with their Retry-After.
+func checkResponse(r *http.Response) error {
+	if r.StatusCode == http.StatusOK {
+		return nil
+	}
+	r.Body.Close()
+	err := errors.Errorf("request failed with message: %v", r.Status)
+	if r.StatusCode == http.StatusTooManyRequests {
+		return &weather.Error{Kind: weather.RateLimited, RetryAfter: parseRetryAfter(r.Header.Get("Retry-After")), Err: err}
+	}
+	return err
+}
+
+// parseRetryAfter parses the Retry-After header given either in seconds or as a date,
+// returning zero when it is missing or malformed.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if retryAfter := time.Until(date); retryAfter > 0 {
+			return retryAfter
+		}
+	}
+	return 0
+}
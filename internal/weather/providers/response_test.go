@@ -0,0 +1,39 @@
+package providers
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+	"weather-reporter/internal/weather"
+)
+
+func Test_Should_Report_Too_Many_Requests_As_Rate_Limited(t *testing.T) {
+	response := &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"120"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+	}
+	err := checkResponse(response)
+	e := weather.AsError(err)
+	assert.Equal(t, weather.RateLimited, e.Kind)
+	assert.Equal(t, 2*time.Minute, e.RetryAfter)
+	assert.Contains(t, err.Error(), "429 Too Many Requests")
+}
+
+func Test_Should_Report_Failed_Response_As_Internal(t *testing.T) {
+	response := &http.Response{StatusCode: http.StatusBadGateway, Body: ioutil.NopCloser(bytes.NewBufferString(""))}
+	assert.Equal(t, weather.Internal, weather.AsError(checkResponse(response)).Kind)
+}
+
+func Test_Should_Parse_Retry_After(t *testing.T) {
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"))
+	retryAfter := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, time.Hour.Seconds(), retryAfter.Seconds(), 2)
+}
@@ -0,0 +1,18 @@
+package providers
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+)
+
+// contactPattern matches an email address or a url, which tells providers whom to contact about the service.
+var contactPattern = regexp.MustCompile(`[^\s@()<>]+@[^\s@()<>]+\.[a-zA-Z]+|https?://[^\s()<>]+`)
+
+// CheckUserAgent checks that the User-Agent includes a contact, e.g. "weather-reporter/1.0 you@example.com",
+// as the terms of service of MET Norway and the US National Weather Service require.
+func CheckUserAgent(userAgent string) error {
+	if !contactPattern.MatchString(userAgent) {
+		return errors.Errorf("user agent %q must include a contact, either an email address or a url", userAgent)
+	}
+	return nil
+}
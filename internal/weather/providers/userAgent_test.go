@@ -0,0 +1,26 @@
+package providers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Should_Accept_User_Agent_With_Contact(t *testing.T) {
+	for _, userAgent := range []string{
+		"weather-reporter/1.0 you@example.com",
+		"weather-reporter/1.0 (+https://example.com/weather)",
+		"weather-reporter/1.0 (http://example.com; ops@example.com)",
+	} {
+		assert.NoError(t, CheckUserAgent(userAgent), userAgent)
+	}
+}
+
+func Test_Should_Return_Error_For_User_Agent_Without_Contact(t *testing.T) {
+	for _, userAgent := range []string{"", "weather-reporter/1.0", "weather-reporter/1.0 @example", "example.com"} {
+		err := CheckUserAgent(userAgent)
+		assert.Error(t, err, userAgent)
+		if err != nil {
+			assert.Contains(t, err.Error(), "must include a contact", userAgent)
+		}
+	}
+}
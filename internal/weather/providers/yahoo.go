@@ -1,22 +1,34 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/oliveagle/jsonpath"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"io"
-	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 	"weather-reporter/internal/weather"
 )
 
 const yahooUrl = "https://query.yahooapis.com/v1/public/yql"
 
+// yahooDateLayout is the layout of condition dates, e.g. "Mon, 22 Oct 2018 09:00 PM AEDT"
+const yahooDateLayout = "Mon, 02 Jan 2006 03:04 PM MST"
+
+// yahooForecastDateLayout is the layout of forecast dates, e.g. "22 Oct 2018"
+const yahooForecastDateLayout = "02 Jan 2006"
+
+const (
+	hPaPerInHg = 33.8639
+	kmPerMile  = 1.609344
+)
+
 func NewYahooWeatherProvider(client http.Client) weather.Provider {
 	return &yahooWeatherProvider{
 		client: client,
@@ -27,24 +39,56 @@ type yahooWeatherProvider struct {
 	client http.Client
 }
 
-func (p *yahooWeatherProvider) Get(city string) (weather.Weather, error) {
-	query := `select item.condition, wind from weather.forecast where woeid in (select woeid from geo.places(1) where text="%v")`
+func (p *yahooWeatherProvider) Get(ctx context.Context, location weather.Location) (weather.Weather, error) {
+	query := `select item.condition, wind, atmosphere from weather.forecast where woeid in (select woeid from geo.places(1) where text="%v")`
+	body, err := p.request(ctx, fmt.Sprintf(query, yahooPlaceText(location)))
+	if err != nil {
+		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to get %v weather", location)
+	}
+	defer body.Close()
+	return p.toWeather(body)
+}
+
+func (p *yahooWeatherProvider) GetForecast(ctx context.Context, location weather.Location, days int) (weather.Forecast, error) {
+	query := `select item.forecast from weather.forecast where woeid in (select woeid from geo.places(1) where text="%v")`
+	body, err := p.request(ctx, fmt.Sprintf(query, yahooPlaceText(location)))
+	if err != nil {
+		return weather.Forecast{}, errors.Wrapf(err, "yahoo: failed to get %v forecast", location)
+	}
+	defer body.Close()
+	return p.toForecast(body, days)
+}
+
+// yahooPlaceText formats the location as a geo.places text, which is either a place name like "sydney, au"
+// or coordinates like "(-33.8688,151.2093)". Quotes are removed, as they would end the text in the query.
+func yahooPlaceText(location weather.Location) string {
+	text := strings.ToLower(location.String())
+	if location.Coordinates != nil {
+		text = fmt.Sprintf("(%v,%v)", location.Coordinates.Latitude, location.Coordinates.Longitude)
+	}
+	return strings.Replace(text, `"`, "", -1)
+}
+
+func (p *yahooWeatherProvider) request(ctx context.Context, query string) (io.ReadCloser, error) {
 	params := url.Values{}
 	params.Set("format", "json")
-	params.Set("q", fmt.Sprintf(query, strings.ToLower(city)))
+	params.Set("q", query)
 	urlString := yahooUrl + "?" + params.Encode()
 	log.WithField("url", urlString).
 		WithField("provider", "yahoo").
 		Debug("sending http request")
-	r, err := p.client.Get(urlString)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	r, err := p.client.Do(request)
 	if err != nil {
-		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to get %v weather", city)
+		return nil, err
 	}
-	if r.StatusCode != 200 {
-		return weather.Weather{}, errors.Errorf("yahoo: request failed with message: %v", r.Status)
+	if err := checkResponse(r); err != nil {
+		return nil, err
 	}
-	defer r.Body.Close()
-	return p.toWeather(r.Body)
+	return r.Body, nil
 }
 
 func (p *yahooWeatherProvider) toWeather(data io.Reader) (weather.Weather, error) {
@@ -53,25 +97,45 @@ func (p *yahooWeatherProvider) toWeather(data io.Reader) (weather.Weather, error
 	if err != nil {
 		return weather.Weather{}, errors.Wrap(err, "yahoo: failed to unmarshal json response")
 	}
+	if results, _ := jsonpath.JsonPathLookup(jsonData, "$.query.results"); results == nil {
+		return weather.Weather{}, errors.Wrap(weather.ErrUnknownLocation, "yahoo: no place matched")
+	}
 	windSpeedStr, err := jsonpath.JsonPathLookup(jsonData, "$.query.results.channel.wind.speed")
 	if err != nil {
 		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to extract wind speed from %v", jsonData)
 	}
-	windSpeed, err := strconv.Atoi(windSpeedStr.(string))
+	windSpeed, err := strconv.ParseFloat(windSpeedStr.(string), 64)
 	if err != nil {
-		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to convert %v to int", windSpeedStr)
+		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to convert %v to number", windSpeedStr)
 	}
 	temperatureDegreesStr, err := jsonpath.JsonPathLookup(jsonData, "$.query.results.channel.item.condition.temp")
 	if err != nil {
 		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to extract temperature degrees from %v", jsonData)
 	}
-	temperatureDegrees, err := strconv.Atoi(temperatureDegreesStr.(string))
+	temperatureDegrees, err := strconv.ParseFloat(temperatureDegreesStr.(string), 64)
 	if err != nil {
-		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to convert %v to int", temperatureDegreesStr)
+		return weather.Weather{}, errors.Wrapf(err, "yahoo: failed to convert %v to number", temperatureDegreesStr)
 	}
+	// the query does not request metric units, so wind speed is in mph, pressure in inHg and visibility in miles
 	w := weather.Weather{
-		WindSpeed:          windSpeed,
-		TemperatureDegrees: p.toCelsius(temperatureDegrees),
+		WindSpeed:          weather.ToWindSpeed(windSpeed, weather.MilesPerHour),
+		TemperatureDegrees: weather.ConvertTemperature(temperatureDegrees, weather.Fahrenheit, weather.Celsius),
+		Units:              weather.CanonicalUnits,
+		WindDirection:      lookupOptionalFloat(jsonData, "$.query.results.channel.wind.direction"),
+		Humidity:           lookupOptionalFloat(jsonData, "$.query.results.channel.atmosphere.humidity"),
+		Pressure:           scaleOptionalFloat(lookupOptionalFloat(jsonData, "$.query.results.channel.atmosphere.pressure"), hPaPerInHg),
+		Visibility:         scaleOptionalFloat(lookupOptionalFloat(jsonData, "$.query.results.channel.atmosphere.visibility"), kmPerMile),
+		Provider:           "yahoo",
+	}
+	if code := lookupOptionalString(jsonData, "$.query.results.channel.item.condition.code"); code != "" {
+		w.Condition = &weather.Condition{
+			Code:        code,
+			Description: lookupOptionalString(jsonData, "$.query.results.channel.item.condition.text"),
+		}
+	}
+	date := lookupOptionalString(jsonData, "$.query.results.channel.item.condition.date")
+	if observedAt, ok := parseYahooDate(date); ok {
+		w.ObservedAt = &observedAt
 	}
 	log.WithField("weather", w).
 		WithField("provider", "yahoo").
@@ -79,7 +143,75 @@ func (p *yahooWeatherProvider) toWeather(data io.Reader) (weather.Weather, error
 	return w, nil
 }
 
-func (p *yahooWeatherProvider) toCelsius(fahrenheit int) int {
-	celsius := (float64(fahrenheit) - 32) * 5 / 9
-	return int(math.Round(celsius))
+// parseYahooDate parses a condition date in UTC. Dates are stamped with the zone abbreviation of the place,
+// e.g. AEDT, which time.Parse can not resolve to an offset, so only dates in UTC or GMT are taken.
+func parseYahooDate(date string) (time.Time, bool) {
+	parsed, err := time.Parse(yahooDateLayout, date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if zone, _ := parsed.Zone(); zone != "UTC" && zone != "GMT" {
+		return time.Time{}, false
+	}
+	return parsed.UTC(), true
+}
+
+type yahooForecast struct {
+	Query struct {
+		Results struct {
+			// Channel has an item per forecast day when item.forecast is selected
+			Channel []struct {
+				Item struct {
+					Forecast struct {
+						Code string `json:"code"`
+						Date string `json:"date"`
+						High string `json:"high"`
+						Low  string `json:"low"`
+						Text string `json:"text"`
+					} `json:"forecast"`
+				} `json:"item"`
+			} `json:"channel"`
+		} `json:"results"`
+	} `json:"query"`
+}
+
+func (p *yahooWeatherProvider) toForecast(data io.Reader, days int) (weather.Forecast, error) {
+	var response yahooForecast
+	err := json.NewDecoder(data).Decode(&response)
+	if err != nil {
+		return weather.Forecast{}, errors.Wrap(err, "yahoo: failed to unmarshal json response")
+	}
+	channel := response.Query.Results.Channel
+	if len(channel) == 0 {
+		return weather.Forecast{}, errors.New("yahoo: forecast has no days")
+	}
+	if len(channel) > days {
+		channel = channel[:days]
+	}
+	forecast := weather.Forecast{Units: weather.CanonicalUnits, Provider: "yahoo"}
+	for _, item := range channel {
+		day := item.Item.Forecast
+		date, err := time.Parse(yahooForecastDateLayout, day.Date)
+		if err != nil {
+			return weather.Forecast{}, errors.Wrapf(err, "yahoo: failed to parse forecast date %v", day.Date)
+		}
+		high, err := strconv.ParseFloat(day.High, 64)
+		if err != nil {
+			return weather.Forecast{}, errors.Wrapf(err, "yahoo: failed to convert %v to number", day.High)
+		}
+		low, err := strconv.ParseFloat(day.Low, 64)
+		if err != nil {
+			return weather.Forecast{}, errors.Wrapf(err, "yahoo: failed to convert %v to number", day.Low)
+		}
+		forecast.Days = append(forecast.Days, weather.DailyForecast{
+			Date:           date.Format("2006-01-02"),
+			TemperatureMin: weather.ConvertTemperature(low, weather.Fahrenheit, weather.Celsius),
+			TemperatureMax: weather.ConvertTemperature(high, weather.Fahrenheit, weather.Celsius),
+			Condition:      &weather.Condition{Code: day.Code, Description: day.Text},
+		})
+	}
+	log.WithField("forecast", forecast).
+		WithField("provider", "yahoo").
+		Debug("got forecast data")
+	return forecast, nil
 }
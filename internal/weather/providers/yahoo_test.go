@@ -1,10 +1,12 @@
 package providers
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
+	"time"
 	"weather-reporter/internal/weather"
 )
 
@@ -15,49 +17,182 @@ func Test_Should_Build_Yahoo_Api_Url(t *testing.T) {
 	}
 	client := NewClientStub("", 0, nil, assertFunc)
 	provider := NewYahooWeatherProvider(client)
-	_, _ = provider.Get(city)
+	_, _ = provider.Get(context.Background(), weather.Location{City: city})
 }
 
 func Test_Should_Return_Error_From_Yahoo_Http_Client(t *testing.T) {
 	msg := "test error message"
 	client := NewClientStub("", 0, errors.New(msg))
 	provider := NewYahooWeatherProvider(client)
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), msg)
 }
 
 func Test_Should_Return_Error_When_Yahoo_Response_Status_Is_Not_OK(t *testing.T) {
 	client := NewClientStub("", 500, nil)
 	provider := NewYahooWeatherProvider(client)
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "request failed")
 }
 
 func Test_Should_Return_Error_When_Yahoo_Response_Is_Not_Valid_Json(t *testing.T) {
 	client := NewClientStub("TEST", 200, nil)
 	provider := NewYahooWeatherProvider(client)
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to unmarshal json")
 }
 
+func Test_Should_Return_Unknown_Location_When_Yahoo_Has_No_Place(t *testing.T) {
+	client := NewClientStub(`{"query":{"count":0,"results":null}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client)
+	_, err := provider.Get(context.Background(), weather.Location{City: "atlantis"})
+	assert.Equal(t, weather.ErrUnknownLocation, errors.Cause(err))
+}
+
 func Test_Should_Return_Error_When_Yahoo_Response_Has_No_Wind_Speed(t *testing.T) {
 	client := NewClientStub(`{"query":{"results":{"channel":{"item":{"condition":{"temp":"0"}}}}}}`, 200, nil)
 	provider := NewYahooWeatherProvider(client)
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to extract wind speed")
 }
 
 func Test_Should_Return_Error_When_Yahoo_Response_Has_No_Temperature(t *testing.T) {
 	client := NewClientStub(`{"query":{"results":{"channel":{"wind":{"speed":"0"}}}}}`, 200, nil)
 	provider := NewYahooWeatherProvider(client)
-	_, err := provider.Get("test")
+	_, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.Contains(t, err.Error(), "failed to extract temperature")
 }
 
 func Test_Should_Return_Weather_From_Yahoo_Response(t *testing.T) {
 	client := NewClientStub(`{"query":{"results":{"channel":{"wind":{"speed":"2"},"item":{"condition":{"temp":"33"}}}}}}`, 200, nil)
 	provider := NewYahooWeatherProvider(client)
-	w, err := provider.Get("test")
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, w.Round(0), weather.Weather{TemperatureDegrees: 1, WindSpeed: 3, Units: weather.CanonicalUnits, Provider: "yahoo"})
+}
+
+func Test_Should_Convert_Yahoo_Wind_Speed_From_Mph(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":{"wind":{"speed":"25"},"item":{"condition":{"temp":"33"}}}}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client)
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 40.2336, w.WindSpeed, 0.0001)
+	assert.Equal(t, weather.KilometresPerHour, w.Units.WindSpeed)
+}
+
+func Test_Should_Send_Yahoo_Request_With_Caller_Context(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	assertFunc := func(req *http.Request) {
+		assert.Equal(t, "value", req.Context().Value(key{}))
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewYahooWeatherProvider(client)
+	_, _ = provider.Get(ctx, weather.Location{City: "test"})
+}
+
+func Test_Should_Keep_Yahoo_Measurement_Precision(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":{"wind":{"speed":"2.5"},"item":{"condition":{"temp":"33.5"}}}}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client)
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 4.0234, w.WindSpeed, 0.0001)
+	assert.InDelta(t, 0.8333, w.TemperatureDegrees, 0.0001)
+}
+
+func Test_Should_Return_Extended_Weather_From_Yahoo_Response(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":{
+		"wind":{"chill":"59","direction":"170","speed":"2"},
+		"atmosphere":{"humidity":"78","pressure":"29.92","rising":"0","visibility":"10"},
+		"item":{"condition":{"code":"30","date":"Mon, 22 Oct 2018 09:00 PM UTC","temp":"33","text":"Partly Cloudy"}}
+	}}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client)
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, float(170), w.WindDirection)
+	assert.Equal(t, float(78), w.Humidity)
+	assert.InDelta(t, 1013.2, *w.Pressure, 0.1)
+	assert.InDelta(t, 16.09, *w.Visibility, 0.01)
+	assert.Nil(t, w.CloudCover)
+	assert.Nil(t, w.WindGust)
+	assert.Equal(t, &weather.Condition{Code: "30", Description: "Partly Cloudy"}, w.Condition)
+	assert.Equal(t, time.Date(2018, 10, 22, 21, 0, 0, 0, time.UTC), *w.ObservedAt)
+}
+
+func Test_Should_Not_Return_Observation_Time_Of_Yahoo_Condition_In_Local_Zone(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":{
+		"wind":{"speed":"2"},
+		"item":{"condition":{"code":"30","date":"Mon, 22 Oct 2018 09:00 PM AEDT","temp":"33","text":"Partly Cloudy"}}
+	}}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client)
+	w, err := provider.Get(context.Background(), weather.Location{City: "test"})
 	assert.NoError(t, err)
-	assert.Equal(t, w, weather.Weather{TemperatureDegrees: 1, WindSpeed: 2})
+	assert.Nil(t, w.ObservedAt)
+}
+
+func Test_Should_Parse_Yahoo_Dates_In_Utc_And_Gmt_Only(t *testing.T) {
+	observedAt, ok := parseYahooDate("Mon, 22 Oct 2018 09:00 AM GMT")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 10, 22, 9, 0, 0, 0, time.UTC), observedAt)
+	for _, date := range []string{"Mon, 22 Oct 2018 09:00 PM AEDT", "Mon, 22 Oct 2018 09:00 PM PDT", ""} {
+		_, ok := parseYahooDate(date)
+		assert.False(t, ok, date)
+	}
+}
+
+func Test_Should_Build_Yahoo_Forecast_Api_Url(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Contains(t, req.URL.Query().Get("q"), "select item.forecast")
+		assert.Contains(t, req.URL.Query().Get("q"), "test-city")
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewYahooWeatherProvider(client).(weather.ForecastProvider)
+	_, _ = provider.GetForecast(context.Background(), weather.Location{City: "test-city"}, 5)
+}
+
+func Test_Should_Return_Error_When_Yahoo_Forecast_Has_No_Days(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":[]}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client).(weather.ForecastProvider)
+	_, err := provider.GetForecast(context.Background(), weather.Location{City: "test"}, 5)
+	assert.Contains(t, err.Error(), "forecast has no days")
+}
+
+func Test_Should_Return_Forecast_From_Yahoo_Response(t *testing.T) {
+	client := NewClientStub(`{"query":{"results":{"channel":[
+		{"item":{"forecast":{"code":"30","date":"22 Oct 2018","day":"Mon","high":"77","low":"50","text":"Partly Cloudy"}}},
+		{"item":{"forecast":{"code":"12","date":"23 Oct 2018","day":"Tue","high":"68","low":"59","text":"Rain"}}},
+		{"item":{"forecast":{"code":"32","date":"24 Oct 2018","day":"Wed","high":"80","low":"60","text":"Sunny"}}}
+	]}}}`, 200, nil)
+	provider := NewYahooWeatherProvider(client).(weather.ForecastProvider)
+	forecast, err := provider.GetForecast(context.Background(), weather.Location{City: "test"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, weather.Forecast{
+		Days: []weather.DailyForecast{
+			{Date: "2018-10-22", TemperatureMin: 10, TemperatureMax: 25,
+				Condition: &weather.Condition{Code: "30", Description: "Partly Cloudy"}},
+			{Date: "2018-10-23", TemperatureMin: 15, TemperatureMax: 20,
+				Condition: &weather.Condition{Code: "12", Description: "Rain"}},
+		},
+		Units:    weather.CanonicalUnits,
+		Provider: "yahoo",
+	}, forecast)
+}
+
+func Test_Should_Query_Yahoo_Places_By_City_And_Country(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Contains(t, req.URL.Query().Get("q"), `geo.places(1) where text="são paulo, br"`)
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewYahooWeatherProvider(client)
+	_, _ = provider.Get(context.Background(), weather.Location{City: "São Paulo", Country: "BR"})
+}
+
+func Test_Should_Query_Yahoo_Places_By_Coordinates(t *testing.T) {
+	assertFunc := func(req *http.Request) {
+		assert.Contains(t, req.URL.Query().Get("q"), `geo.places(1) where text="(-33.8688,151.2093)"`)
+	}
+	client := NewClientStub("", 0, nil, assertFunc)
+	provider := NewYahooWeatherProvider(client)
+	location := weather.Location{Coordinates: &weather.Coordinates{Latitude: -33.8688, Longitude: 151.2093}}
+	_, _ = provider.Get(context.Background(), location)
 }
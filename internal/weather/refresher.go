@@ -0,0 +1,85 @@
+package weather
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+// refresher runs background cache refreshes with bounded concurrency
+// and gives up on a key after maxAttempts consecutive failed refreshes.
+type refresher struct {
+	slots       chan struct{}
+	maxAttempts int
+	mu          sync.Mutex
+	failures    map[string]int
+	inFlight    map[string]bool
+}
+
+func newRefresher(concurrency int, maxAttempts int) *refresher {
+	return &refresher{
+		slots:       make(chan struct{}, concurrency),
+		maxAttempts: maxAttempts,
+		failures:    make(map[string]int),
+		inFlight:    make(map[string]bool),
+	}
+}
+
+// canRefresh reports whether a background refresh may still be attempted for the key.
+func (r *refresher) canRefresh(key string) bool {
+	if cap(r.slots) == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxAttempts <= 0 || r.failures[key] < r.maxAttempts
+}
+
+// refresh starts the refresh function in background unless the key is already being refreshed
+// or all refresh slots are busy.
+func (r *refresher) refresh(key string, fn func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inFlight[key] {
+		return
+	}
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		return
+	}
+	r.inFlight[key] = true
+	refreshMetric.WithLabelValues("started").Inc()
+	go func() {
+		err := fn()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.inFlight, key)
+		<-r.slots
+		if err != nil {
+			refreshMetric.WithLabelValues("failed").Inc()
+			r.failures[key]++
+			return
+		}
+		refreshMetric.WithLabelValues("succeeded").Inc()
+		delete(r.failures, key)
+	}()
+}
+
+// reset clears failed attempts of the key, e.g. once it has been fetched from a provider.
+func (r *refresher) reset(key string) {
+	r.mu.Lock()
+	delete(r.failures, key)
+	r.mu.Unlock()
+}
+
+var refreshMetric = registerRefreshMetric()
+
+func registerRefreshMetric() *prometheus.CounterVec {
+	metric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weather_reporter",
+		Name:      "cache_refreshes_total",
+		Help:      "Counter of background cache refreshes started, succeeded or failed.",
+	}, []string{"result"})
+	prometheus.MustRegister(metric)
+	return metric
+}
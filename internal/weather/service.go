@@ -1,59 +1,75 @@
 package weather
 
 import (
-	"fmt"
-	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
+	"context"
+	"time"
 )
 
 type Service interface {
-	GetCurrentWeather(city string) (Weather, error)
+	GetCurrentWeather(ctx context.Context, location Location) (Weather, Metadata, error)
 }
 
-func NewWeatherService(cache Cache, weatherProviders ...Provider) Service {
+type ServiceConfig struct {
+	// RefreshConcurrency limits the number of background cache refreshes running at once.
+	// Background refresh is disabled when it is zero.
+	RefreshConcurrency int
+	// RefreshMaxAttempts limits consecutive failed background refreshes of a location,
+	// after that the location is fetched synchronously until a provider succeeds.
+	RefreshMaxAttempts int
+	// HedgeDelay is the time to wait for a provider before calling the next one in parallel.
+	// Providers are called sequentially when it is zero.
+	HedgeDelay time.Duration
+	// RequestBudget is the overall deadline of a lookup, split across providers.
+	// Lookups are bound only by the caller context when it is zero.
+	RequestBudget time.Duration
+	// Resolver resolves locations to canonical places before looking them up,
+	// so that every spelling of a place shares a cache entry and unknown places never reach providers.
+	// Locations are looked up as given when it is nil.
+	Resolver Resolver
+}
+
+func NewWeatherService(cache Cache, config ServiceConfig, weatherProviders ...Provider) Service {
 	return &service{
-		weatherProviders: weatherProviders,
-		cache:            cache,
+		chain: newProviderChain("weather", &cacheAdapter[Weather]{cache}, config, weatherProviders),
 	}
 }
 
 type service struct {
-	weatherProviders []Provider
-	cache            Cache
+	chain *providerChain
 }
 
-func (s *service) GetCurrentWeather(city string) (Weather, error) {
-	log.WithField("city", city).Debug("searching for weather")
-	weather, err := s.getWeatherFromProvider(city)
-	if err == nil {
-		return weather, nil
-	}
-	err = errors.Wrapf(err, "failed to get %v weather from providers", city)
-	weather, found := s.cache.Get(city)
-	if found {
-		log.WithField("city", city).
-			WithField("error", fmt.Sprintf("%+v", err)).
-			Warn("failed to get weather from provider; cached result will be returned")
-		return weather, nil
+func (s *service) GetCurrentWeather(ctx context.Context, location Location) (Weather, Metadata, error) {
+	value, metadata, err := s.chain.get(ctx, location, getCurrentWeather)
+	if err != nil {
+		return Weather{}, Metadata{}, err
 	}
-	return Weather{}, err
+	w := value.(Weather)
+	metadata.Provider = w.Provider
+	metadata.ObservedAt = w.ObservedAt
+	return w, metadata, nil
+}
+
+func getCurrentWeather(ctx context.Context, provider Provider, location Location) (interface{}, error) {
+	return provider.Get(ctx, location)
 }
 
-func (s *service) getWeatherFromProvider(city string) (Weather, error) {
-	if len(s.weatherProviders) == 0 {
-		return Weather{}, errors.New("no providers configured")
+// cacheAdapter lets the provider chain use a cache of values of a type,
+// which may be provided from outside of the package.
+type cacheAdapter[T any] struct {
+	cache interface {
+		Get(location Location) (T, Freshness)
+		Put(location Location, value T)
 	}
-	var lastError error
-	for _, currentProvider := range s.weatherProviders {
-		weather, err := currentProvider.Get(city)
-		if err == nil {
-			s.cache.Put(city, weather)
-			return weather, nil
-		}
-		log.WithField("city", city).
-			WithField("error", err).
-			Warn("failed to get weather from provider")
-		lastError = errors.Wrapf(err, "failed to get %v weather from provider", city)
+}
+
+func (a *cacheAdapter[T]) get(location Location) cachedValue {
+	if c, ok := a.cache.(agedCache); ok {
+		return c.lookup(location)
 	}
-	return Weather{}, lastError
+	value, freshness := a.cache.Get(location)
+	return cachedValue{value: value, freshness: freshness}
+}
+
+func (a *cacheAdapter[T]) put(location Location, value interface{}) {
+	a.cache.Put(location, value.(T))
 }
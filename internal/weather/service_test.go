@@ -1,46 +1,50 @@
 package weather
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_Should_Return_Error_When_No_Providers_Configured(t *testing.T) {
 	cache := new(cacheMock)
-	cache.On("Get", mock.Anything).Return(Weather{}, false)
-	service := NewWeatherService(cache)
-	_, err := service.GetCurrentWeather("")
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	service := NewWeatherService(cache, ServiceConfig{})
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: ""})
 	assert.Contains(t, err.Error(), "no providers configured")
 }
 
 func Test_Should_Return_Last_Error_When_All_Providers_Failed(t *testing.T) {
 	cache := new(cacheMock)
-	cache.On("Get", mock.Anything).Return(Weather{}, false)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
 	p1 := provider(func(city string) (weather Weather, e error) {
 		return Weather{}, errors.New("error-1")
 	})
 	p2 := provider(func(city string) (weather Weather, e error) {
 		return Weather{}, errors.New("error-2")
 	})
-	service := NewWeatherService(cache, p1, p2)
-	_, err := service.GetCurrentWeather("")
+	service := NewWeatherService(cache, ServiceConfig{}, p1, p2)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: ""})
 	assert.Contains(t, err.Error(), "error-2")
 }
 
 func Test_Should_Return_Weather_From_Cache_When_All_Providers_Failed(t *testing.T) {
 	weather := Weather{TemperatureDegrees: 1}
 	cache := new(cacheMock)
-	cache.On("Get", "test").Return(weather, true)
+	cache.On("Get", "test").Return(weather, Stale)
 	p1 := provider(func(city string) (weather Weather, e error) {
 		return Weather{}, errors.New("error-1")
 	})
 	p2 := provider(func(city string) (weather Weather, e error) {
 		return Weather{}, errors.New("error-2")
 	})
-	service := NewWeatherService(cache, p1, p2)
-	actualWeather, err := service.GetCurrentWeather("test")
+	service := NewWeatherService(cache, ServiceConfig{}, p1, p2)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
 	assert.NoError(t, err)
 	assert.Equal(t, weather, actualWeather)
 }
@@ -52,14 +56,44 @@ func Test_Should_Put_Weather_From_Provider_To_Cache(t *testing.T) {
 		return weather, nil
 	})
 	cache := new(cacheMock)
+	cache.On("Get", city).Return(Weather{}, Missing)
 	cache.On("Put", city, weather).Once()
-	service := NewWeatherService(cache, p)
-	_, _ = service.GetCurrentWeather(city)
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	_, _, _ = service.GetCurrentWeather(context.Background(), Location{City: city})
+	cache.AssertExpectations(t)
+}
+
+func Test_Should_Return_Fresh_Weather_From_Cache_Without_Calling_Providers(t *testing.T) {
+	weather := Weather{TemperatureDegrees: 1}
+	cache := new(cacheMock)
+	cache.On("Get", "test").Return(weather, Fresh)
+	p := provider(func(city string) (Weather, error) {
+		t.Fatal("provider must not be called for a fresh cache entry")
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Prefer_Provider_Over_Stale_Cache(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", "test").Return(Weather{TemperatureDegrees: 1}, Stale)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	weather := Weather{TemperatureDegrees: 2}
+	p := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
 }
 
 func Test_Should_Return_Weather_From_Provider(t *testing.T) {
 	cache := new(cacheMock)
-	cache.On("Get", mock.Anything).Return(Weather{}, false)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
 	cache.On("Put", mock.Anything, mock.Anything).Maybe()
 	city := "test"
 	weather := Weather{TemperatureDegrees: 1}
@@ -69,33 +103,476 @@ func Test_Should_Return_Weather_From_Provider(t *testing.T) {
 		}
 		return Weather{}, errors.New("unexpected error")
 	})
-	service := NewWeatherService(cache, p)
-	actualWeather, err := service.GetCurrentWeather(city)
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: city})
 	assert.NoError(t, err)
 	assert.Equal(t, weather, actualWeather)
 }
 
+func Test_Should_Call_Provider_Once_For_Concurrent_Lookups_Of_Same_City(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	weather := Weather{TemperatureDegrees: 1}
+	var calls int32
+	release := make(chan struct{})
+	p := provider(func(city string) (Weather, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		city := "sydney"
+		if i%2 == 0 {
+			city = "Sydney"
+		}
+		go func() {
+			defer wg.Done()
+			actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: city})
+			assert.NoError(t, err)
+			assert.Equal(t, weather, actualWeather)
+		}()
+	}
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_Should_Not_Coalesce_Lookups_Of_Different_Cities(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	var calls int32
+	release := make(chan struct{})
+	p := provider(func(city string) (Weather, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+
+	var wg sync.WaitGroup
+	for _, city := range []string{"sydney", "melbourne"} {
+		wg.Add(1)
+		go func(city string) {
+			defer wg.Done()
+			_, _, _ = service.GetCurrentWeather(context.Background(), Location{City: city})
+		}(city)
+	}
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Should_Share_Provider_Error_Between_Coalesced_Lookups(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	release := make(chan struct{})
+	p := provider(func(city string) (Weather, error) {
+		<-release
+		return Weather{}, errors.New("error-1")
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "sydney"})
+			assert.Error(t, err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+}
+
+func Test_Should_Return_Revalidate_Weather_And_Refresh_It_In_Background(t *testing.T) {
+	cached := Weather{TemperatureDegrees: 1}
+	weather := Weather{TemperatureDegrees: 2}
+	cache := new(cacheMock)
+	cache.On("Get", "test").Return(cached, Revalidate)
+	refreshed := make(chan Weather, 1)
+	cache.On("Put", "test", weather).Run(func(args mock.Arguments) {
+		refreshed <- args.Get(1).(Weather)
+	})
+	release := make(chan struct{})
+	p := provider(func(city string) (Weather, error) {
+		<-release
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{RefreshConcurrency: 1}, p)
+
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, cached, actualWeather)
+
+	close(release)
+	select {
+	case w := <-refreshed:
+		assert.Equal(t, weather, w)
+	case <-time.After(time.Second):
+		t.Fatal("cache was not refreshed in background")
+	}
+}
+
+func Test_Should_Start_One_Background_Refresh_Per_City(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Revalidate)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	var calls int32
+	release := make(chan struct{})
+	p := provider(func(city string) (Weather, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{RefreshConcurrency: 10}, p)
+
+	for i := 0; i < 10; i++ {
+		_, _, _ = service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	}
+	close(release)
+	time.Sleep(time.Millisecond * 100)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_Should_Fetch_Synchronously_When_Refresh_Attempts_Are_Exhausted(t *testing.T) {
+	cached := Weather{TemperatureDegrees: 1}
+	cache := new(cacheMock)
+	cache.On("Get", "test").Return(cached, Revalidate)
+	var calls int32
+	p := provider(func(city string) (Weather, error) {
+		atomic.AddInt32(&calls, 1)
+		return Weather{}, errors.New("error-1")
+	})
+	service := NewWeatherService(cache, ServiceConfig{RefreshConcurrency: 1, RefreshMaxAttempts: 1}, p)
+
+	_, _, _ = service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	time.Sleep(time.Millisecond * 100)
+
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, cached, actualWeather)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_Should_Fetch_Synchronously_When_Background_Refresh_Is_Disabled(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", "test").Return(Weather{TemperatureDegrees: 1}, Revalidate)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	weather := Weather{TemperatureDegrees: 2}
+	p := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Hedge_Request_To_Next_Provider_When_Primary_Is_Slow(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	release := make(chan struct{})
+	defer close(release)
+	p1 := provider(func(city string) (Weather, error) {
+		<-release
+		return Weather{TemperatureDegrees: 1}, nil
+	})
+	weather := Weather{TemperatureDegrees: 2}
+	p2 := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Millisecond * 10}, p1, p2)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Not_Hedge_Request_When_Primary_Answers_Within_Hedge_Delay(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	weather := Weather{TemperatureDegrees: 1}
+	p1 := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	p2 := provider(func(city string) (Weather, error) {
+		t.Fatal("secondary provider must not be called")
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Second}, p1, p2)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Fail_Over_Immediately_When_Hedged_Primary_Fails(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	p1 := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-1")
+	})
+	weather := Weather{TemperatureDegrees: 2}
+	p2 := provider(func(city string) (Weather, error) {
+		return weather, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Hour}, p1, p2)
+	actualWeather, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, weather, actualWeather)
+}
+
+func Test_Should_Return_Error_When_All_Hedged_Providers_Failed(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	p1 := provider(func(city string) (Weather, error) {
+		time.Sleep(time.Millisecond * 20)
+		return Weather{}, errors.New("error-1")
+	})
+	p2 := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.New("error-2")
+	})
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Millisecond}, p1, p2)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.Contains(t, err.Error(), "error-1")
+}
+
+func Test_Should_Apply_Request_Budget_To_Provider(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	var deadlineSet bool
+	p := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		_, deadlineSet = ctx.Deadline()
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{RequestBudget: time.Second}, p)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	assert.True(t, deadlineSet)
+}
+
+func Test_Should_Split_Request_Budget_Across_Providers(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	var firstBudget time.Duration
+	p1 := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		deadline, _ := ctx.Deadline()
+		firstBudget = time.Until(deadline)
+		<-ctx.Done()
+		return Weather{}, ctx.Err()
+	})
+	p2 := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		return Weather{}, errors.New("error-2")
+	})
+	service := NewWeatherService(cache, ServiceConfig{RequestBudget: time.Millisecond * 200}, p1, p2)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.Contains(t, err.Error(), "error-2")
+	assert.True(t, firstBudget <= time.Millisecond*100, "first provider budget %v", firstBudget)
+	assert.True(t, firstBudget > time.Millisecond*50, "first provider budget %v", firstBudget)
+}
+
+func Test_Should_Cancel_Provider_When_Caller_Gives_Up(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cancelled := make(chan struct{})
+	p := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return Weather{}, ctx.Err()
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+	_, _, err := service.GetCurrentWeather(ctx, Location{City: "test"})
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("provider was not cancelled")
+	}
+}
+
+func Test_Should_Not_Cancel_Coalesced_Provider_Call_While_Other_Callers_Wait(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	release := make(chan struct{})
+	weather := Weather{TemperatureDegrees: 1}
+	p := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		select {
+		case <-release:
+			return weather, nil
+		case <-ctx.Done():
+			return Weather{}, ctx.Err()
+		}
+	})
+	service := NewWeatherService(cache, ServiceConfig{}, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		_, _, _ = service.GetCurrentWeather(ctx, Location{City: "test"})
+		close(leaderDone)
+	}()
+	time.Sleep(time.Millisecond * 10)
+	waiterDone := make(chan Weather)
+	go func() {
+		w, _, _ := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+		waiterDone <- w
+	}()
+	time.Sleep(time.Millisecond * 10)
+	cancel()
+	<-leaderDone
+	close(release)
+	assert.Equal(t, weather, <-waiterDone)
+}
+
+func Test_Should_Cancel_Hedged_Provider_That_Lost(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	cancelled := make(chan struct{})
+	p1 := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return Weather{}, ctx.Err()
+	})
+	p2 := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Millisecond}, p1, p2)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+	assert.NoError(t, err)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing provider was not cancelled")
+	}
+}
+
+func Test_Should_Not_Open_Breaker_Of_Hedged_Provider_That_Lost(t *testing.T) {
+	cache := new(cacheMock)
+	cache.On("Get", mock.Anything).Return(Weather{}, Missing)
+	cache.On("Put", mock.Anything, mock.Anything).Maybe()
+	lost := make(chan error, 10)
+	p1 := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		<-ctx.Done()
+		lost <- ctx.Err()
+		return Weather{}, ctx.Err()
+	})
+	p2 := provider(func(city string) (Weather, error) {
+		return Weather{}, nil
+	})
+	breaker := NewCircuitBreakerProvider("test", p1, testBreakerConfig)
+	service := NewWeatherService(cache, ServiceConfig{HedgeDelay: time.Millisecond}, breaker, p2)
+	for i := 0; i < 5; i++ {
+		_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "test"})
+		assert.NoError(t, err)
+		select {
+		case err := <-lost:
+			assert.Equal(t, context.Canceled, err)
+		case <-time.After(time.Second):
+			t.Fatal("losing provider was not cancelled")
+		}
+	}
+	assert.Equal(t, Closed, breaker.State())
+}
+
 type cacheMock struct {
 	mock.Mock
 }
 
-func (c *cacheMock) Get(city string) (Weather, bool) {
-	args := c.Called(city)
-	return args.Get(0).(Weather), args.Bool(1)
+func (c *cacheMock) Get(location Location) (Weather, Freshness) {
+	args := c.Called(location.String())
+	return args.Get(0).(Weather), args.Get(1).(Freshness)
 }
 
-func (c *cacheMock) Put(city string, weather Weather) {
-	c.Called(city, weather)
+func (c *cacheMock) Put(location Location, weather Weather) {
+	c.Called(location.String(), weather)
 }
 
 type providerStub struct {
-	handler func(city string) (Weather, error)
+	handler func(ctx context.Context, city string) (Weather, error)
 }
 
-func (p *providerStub) Get(city string) (Weather, error) {
-	return p.handler(city)
+func (p *providerStub) Get(ctx context.Context, location Location) (Weather, error) {
+	return p.handler(ctx, location.String())
 }
 
 func provider(handler func(city string) (Weather, error)) Provider {
+	return contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		return handler(city)
+	})
+}
+
+func contextProvider(handler func(ctx context.Context, city string) (Weather, error)) Provider {
 	return &providerStub{handler: handler}
 }
+
+func Test_Should_Look_Resolved_Location_Up(t *testing.T) {
+	resolved := Location{ID: "test:1", City: "Sydney", Country: "AU"}
+	p := contextProvider(func(ctx context.Context, city string) (Weather, error) {
+		assert.Equal(t, "Sydney, AU", city)
+		return Weather{TemperatureDegrees: 1}, nil
+	})
+	config := ServiceConfig{Resolver: resolverFunc(func(location Location) (Location, error) {
+		return resolved, nil
+	})}
+	cache := NewWeatherCache(time.Minute, time.Minute, time.Minute)
+	service := NewWeatherService(cache, config, p)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "sydeny"})
+	assert.NoError(t, err)
+	_, freshness := cache.Get(resolved)
+	assert.Equal(t, Fresh, freshness)
+}
+
+func Test_Should_Not_Call_Providers_For_Unknown_Location(t *testing.T) {
+	p := provider(func(city string) (Weather, error) {
+		t.Fatal("provider must not be called")
+		return Weather{}, nil
+	})
+	config := ServiceConfig{Resolver: resolverFunc(func(location Location) (Location, error) {
+		return Location{}, ErrUnknownLocation
+	})}
+	service := NewWeatherService(NewWeatherCache(time.Minute, time.Minute, time.Minute), config, p)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "atlantis"})
+	assert.Equal(t, ErrUnknownLocation, errors.Cause(err))
+}
+
+func Test_Should_Return_Unknown_Location_When_Providers_Do_Not_Know_It(t *testing.T) {
+	p1 := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.Wrap(ErrUnknownLocation, "test")
+	})
+	p2 := provider(func(city string) (Weather, error) {
+		return Weather{}, errors.Wrap(ErrNotSupported, "test")
+	})
+	service := NewWeatherService(NewWeatherCache(time.Minute, time.Minute, time.Minute), ServiceConfig{}, p1, p2)
+	_, _, err := service.GetCurrentWeather(context.Background(), Location{City: "atlantis"})
+	assert.Equal(t, ErrUnknownLocation, errors.Cause(err))
+	assert.Equal(t, UnknownLocation, AsError(err).Kind)
+	assert.Equal(t, "atlantis: unknown location", ErrorDetail(err))
+}
+
+type resolverFunc func(location Location) (Location, error)
+
+func (f resolverFunc) Resolve(location Location) (Location, error) {
+	return f(location)
+}
@@ -0,0 +1,171 @@
+package weather
+
+// SpeedUnit is a unit of wind speed.
+type SpeedUnit string
+
+const (
+	KilometresPerHour SpeedUnit = "km/h"
+	MilesPerHour      SpeedUnit = "mph"
+	MetresPerSecond   SpeedUnit = "m/s"
+	Knots             SpeedUnit = "knots"
+)
+
+// metresPerSecond is the number of metres per second in one unit of speed.
+var metresPerSecond = map[SpeedUnit]float64{
+	KilometresPerHour: 1000.0 / 3600.0,
+	MilesPerHour:      1609.344 / 3600.0,
+	MetresPerSecond:   1,
+	Knots:             1852.0 / 3600.0,
+}
+
+// TemperatureUnit is a unit of temperature.
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "celsius"
+	Fahrenheit TemperatureUnit = "fahrenheit"
+	Kelvin     TemperatureUnit = "kelvin"
+)
+
+// Units declares the units of Weather measurements.
+type Units struct {
+	WindSpeed   SpeedUnit       `json:"wind_speed"`
+	Temperature TemperatureUnit `json:"temperature"`
+}
+
+// CanonicalUnits are the units providers convert their measurements into.
+var CanonicalUnits = Units{
+	WindSpeed:   KilometresPerHour,
+	Temperature: Celsius,
+}
+
+// orCanonical fills the units that are not declared with the canonical units,
+// e.g. of weather cached before units were declared.
+func (u Units) orCanonical() Units {
+	return Units{WindSpeed: speedUnitOrCanonical(u.WindSpeed), Temperature: temperatureUnitOrCanonical(u.Temperature)}
+}
+
+// UnitSystems maps unit system names to their units.
+var UnitSystems = map[string]Units{
+	"metric":   {WindSpeed: KilometresPerHour, Temperature: Celsius},
+	"imperial": {WindSpeed: MilesPerHour, Temperature: Fahrenheit},
+	"si":       {WindSpeed: MetresPerSecond, Temperature: Kelvin},
+}
+
+// SpeedUnitCodes maps short wind speed unit codes to their units.
+var SpeedUnitCodes = map[string]SpeedUnit{
+	"kmh":   KilometresPerHour,
+	"mph":   MilesPerHour,
+	"ms":    MetresPerSecond,
+	"knots": Knots,
+}
+
+// TemperatureUnitCodes maps short temperature unit codes to their units.
+var TemperatureUnitCodes = map[string]TemperatureUnit{
+	"c": Celsius,
+	"f": Fahrenheit,
+	"k": Kelvin,
+}
+
+// ParseUnits resolves units from a unit system name with optional wind speed and temperature unit codes
+// overriding it. Empty values fall back to the canonical units.
+func ParseUnits(system string, windCode string, temperatureCode string) (Units, error) {
+	units := CanonicalUnits
+	if system != "" {
+		systemUnits, found := UnitSystems[system]
+		if !found {
+			return Units{}, invalidInputf("unknown unit system %q", system)
+		}
+		units = systemUnits
+	}
+	if windCode != "" {
+		windSpeed, found := SpeedUnitCodes[windCode]
+		if !found {
+			return Units{}, invalidInputf("unknown wind speed unit %q", windCode)
+		}
+		units.WindSpeed = windSpeed
+	}
+	if temperatureCode != "" {
+		temperature, found := TemperatureUnitCodes[temperatureCode]
+		if !found {
+			return Units{}, invalidInputf("unknown temperature unit %q", temperatureCode)
+		}
+		units.Temperature = temperature
+	}
+	return units, nil
+}
+
+// Convert returns the weather with its measurements converted to the units.
+func (w Weather) Convert(units Units) Weather {
+	converted := w
+	converted.WindSpeed = ConvertSpeed(w.WindSpeed, w.Units.WindSpeed, units.WindSpeed)
+	converted.TemperatureDegrees = ConvertTemperature(w.TemperatureDegrees, w.Units.Temperature, units.Temperature)
+	if w.WindGust != nil {
+		windGust := ConvertSpeed(*w.WindGust, w.Units.WindSpeed, units.WindSpeed)
+		converted.WindGust = &windGust
+	}
+	converted.Units = units.orCanonical()
+	return converted
+}
+
+// ConvertTemperature converts the temperature from one unit to another. An empty unit is the canonical unit.
+func ConvertTemperature(temperature float64, from TemperatureUnit, to TemperatureUnit) float64 {
+	from, to = temperatureUnitOrCanonical(from), temperatureUnitOrCanonical(to)
+	if from == to {
+		return temperature
+	}
+	var celsius float64
+	switch from {
+	case Fahrenheit:
+		celsius = (temperature - 32) * 5 / 9
+	case Kelvin:
+		celsius = temperature - 273.15
+	default:
+		celsius = temperature
+	}
+	switch to {
+	case Fahrenheit:
+		return celsius*9/5 + 32
+	case Kelvin:
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
+
+// ConvertSpeed converts the speed from one unit to another. An empty unit is the canonical unit.
+func ConvertSpeed(speed float64, from SpeedUnit, to SpeedUnit) float64 {
+	from, to = speedUnitOrCanonical(from), speedUnitOrCanonical(to)
+	if from == to {
+		return speed
+	}
+	return speed * metresPerSecond[from] / metresPerSecond[to]
+}
+
+func speedUnitOrCanonical(unit SpeedUnit) SpeedUnit {
+	if unit == "" {
+		return CanonicalUnits.WindSpeed
+	}
+	return unit
+}
+
+func temperatureUnitOrCanonical(unit TemperatureUnit) TemperatureUnit {
+	if unit == "" {
+		return CanonicalUnits.Temperature
+	}
+	return unit
+}
+
+// ToWindSpeed converts the speed to the canonical wind speed unit.
+func ToWindSpeed(speed float64, unit SpeedUnit) float64 {
+	return ConvertSpeed(speed, unit, CanonicalUnits.WindSpeed)
+}
+
+// ToOptionalWindSpeed converts the speed like ToWindSpeed, keeping a missing speed missing.
+func ToOptionalWindSpeed(speed *float64, unit SpeedUnit) *float64 {
+	if speed == nil {
+		return nil
+	}
+	windSpeed := ToWindSpeed(*speed, unit)
+	return &windSpeed
+}
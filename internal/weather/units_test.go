@@ -0,0 +1,90 @@
+package weather
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Should_Convert_Speed_Between_Units(t *testing.T) {
+	assert.InDelta(t, 36, ConvertSpeed(10, MetresPerSecond, KilometresPerHour), 0.0001)
+	assert.InDelta(t, 16.0934, ConvertSpeed(10, MilesPerHour, KilometresPerHour), 0.0001)
+	assert.InDelta(t, 18.52, ConvertSpeed(10, Knots, KilometresPerHour), 0.0001)
+	assert.InDelta(t, 2.7778, ConvertSpeed(10, KilometresPerHour, MetresPerSecond), 0.0001)
+	assert.Equal(t, 10.0, ConvertSpeed(10, MilesPerHour, MilesPerHour))
+}
+
+func Test_Should_Convert_Wind_Speed_To_Canonical_Unit(t *testing.T) {
+	assert.InDelta(t, 7.2, ToWindSpeed(2, MetresPerSecond), 0.0001)
+	assert.InDelta(t, 3.2187, ToWindSpeed(2, MilesPerHour), 0.0001)
+	assert.Equal(t, 2.0, ToWindSpeed(2, KilometresPerHour))
+}
+
+func Test_Should_Convert_Temperature_Between_Units(t *testing.T) {
+	assert.InDelta(t, 1, ConvertTemperature(33.8, Fahrenheit, Celsius), 0.0001)
+	assert.InDelta(t, 77, ConvertTemperature(25, Celsius, Fahrenheit), 0.0001)
+	assert.InDelta(t, 298.15, ConvertTemperature(25, Celsius, Kelvin), 0.0001)
+	assert.InDelta(t, 32, ConvertTemperature(273.15, Kelvin, Fahrenheit), 0.0001)
+	assert.Equal(t, 25.0, ConvertTemperature(25, Kelvin, Kelvin))
+}
+
+func Test_Should_Parse_Canonical_Units_By_Default(t *testing.T) {
+	units, err := ParseUnits("", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, CanonicalUnits, units)
+}
+
+func Test_Should_Parse_Unit_Systems(t *testing.T) {
+	units, err := ParseUnits("imperial", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, Units{WindSpeed: MilesPerHour, Temperature: Fahrenheit}, units)
+
+	units, err = ParseUnits("si", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, Units{WindSpeed: MetresPerSecond, Temperature: Kelvin}, units)
+}
+
+func Test_Should_Override_Unit_System_With_Unit_Codes(t *testing.T) {
+	units, err := ParseUnits("imperial", "knots", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, Units{WindSpeed: Knots, Temperature: Celsius}, units)
+}
+
+func Test_Should_Return_Error_For_Unknown_Units(t *testing.T) {
+	_, err := ParseUnits("nautical", "", "")
+	assert.Contains(t, err.Error(), "unknown unit system")
+	_, err = ParseUnits("", "kmph", "")
+	assert.Contains(t, err.Error(), "unknown wind speed unit")
+	_, err = ParseUnits("", "", "r")
+	assert.Contains(t, err.Error(), "unknown temperature unit")
+}
+
+func Test_Should_Convert_Weather_To_Units(t *testing.T) {
+	w := Weather{WindSpeed: 36, TemperatureDegrees: 25, Units: CanonicalUnits}
+	converted := w.Convert(Units{WindSpeed: MetresPerSecond, Temperature: Fahrenheit})
+	assert.InDelta(t, 10, converted.WindSpeed, 0.0001)
+	assert.InDelta(t, 77, converted.TemperatureDegrees, 0.0001)
+	assert.Equal(t, Units{WindSpeed: MetresPerSecond, Temperature: Fahrenheit}, converted.Units)
+}
+
+func Test_Should_Convert_Weather_Wind_Gust_To_Units(t *testing.T) {
+	windGust := 36.0
+	w := Weather{WindGust: &windGust, Units: CanonicalUnits}
+	converted := w.Convert(Units{WindSpeed: MetresPerSecond, Temperature: Celsius})
+	assert.InDelta(t, 10, *converted.WindGust, 0.0001)
+	assert.Equal(t, 36.0, windGust)
+}
+
+func Test_Should_Treat_Empty_Units_As_Canonical(t *testing.T) {
+	assert.Equal(t, 36.0, ConvertSpeed(36, "", KilometresPerHour))
+	assert.InDelta(t, 10, ConvertSpeed(36, "", MetresPerSecond), 0.0001)
+	assert.InDelta(t, 36, ConvertSpeed(10, MetresPerSecond, ""), 0.0001)
+	assert.InDelta(t, 298.15, ConvertTemperature(25, "", Kelvin), 0.0001)
+
+	windGust := 36.0
+	w := Weather{WindSpeed: 36, WindGust: &windGust, TemperatureDegrees: 25}
+	converted := w.Convert(Units{WindSpeed: MetresPerSecond})
+	assert.InDelta(t, 10, converted.WindSpeed, 0.0001)
+	assert.InDelta(t, 10, *converted.WindGust, 0.0001)
+	assert.Equal(t, 25.0, converted.TemperatureDegrees)
+	assert.Equal(t, Units{WindSpeed: MetresPerSecond, Temperature: Celsius}, converted.Units)
+}